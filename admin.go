@@ -0,0 +1,445 @@
+package smartqueue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultPort       = 8098
+	tenantSpecificUrl = `/smartqueue/tenant/`
+)
+
+type tenantView[K comparable, V any] struct {
+	Key   K `json:"key"`
+	Value V `json:"value"`
+	// ExpiryTime is in Unix seconds, and 0 for a persisted item
+	ExpiryTime int64         `json:"expiry_time"`
+	TTL        time.Duration `json:"ttl_remaining"`
+}
+
+// newTenantView builds the view of an item expiring at expiresAt, which is zero when it is persisted.
+func newTenantView[K comparable, V any](key K, value V, expiresAt, now time.Time) tenantView[K, V] {
+	if expiresAt.IsZero() {
+		return tenantView[K, V]{Key: key, Value: value, TTL: NoExpiry}
+	}
+	return tenantView[K, V]{
+		Key:        key,
+		Value:      value,
+		ExpiryTime: expiresAt.Unix(),
+		TTL:        max(expiresAt.Sub(now), 0),
+	}
+}
+
+// entryRequest is the body of PUT and POST on an entry. TTL is a duration such as "30s".
+type entryRequest[V any] struct {
+	Value    V        `json:"value"`
+	TTL      string   `json:"ttl"`
+	Priority Priority `json:"priority"`
+}
+
+// tenantConfigView is the JSON form of TenantConfig, with durations such as "30s" and policies by name.
+type tenantConfigView struct {
+	Capacity       int64  `json:"capacity"`
+	DefaultTTL     string `json:"default_ttl"`
+	MaxTTL         string `json:"max_ttl"`
+	EvictionPolicy string `json:"eviction_policy"`
+	Weight         int64  `json:"weight"`
+}
+
+type errorView struct {
+	Error string `json:"error"`
+}
+
+// methodHandlers serves one path with a handler per HTTP method.
+type methodHandlers map[string]http.HandlerFunc
+
+func (m methodHandlers) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if handler, ok := m[r.Method]; ok {
+		handler(w, r)
+		return
+	}
+	allowed := make([]string, 0, len(m))
+	for method := range m {
+		allowed = append(allowed, method)
+	}
+	slices.Sort(allowed)
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+}
+
+// AdminHandler returns the HTTP admin API, to be mounted on any server:
+//
+//	GET    /smartqueue/tenants                          list the tenants with their counts
+//	GET    /smartqueue/tenant/{id}                      list the items of a tenant
+//	POST   /smartqueue/tenant/{id}                      dequeue the front item
+//	PUT    /smartqueue/tenant/{id}                      set the tenant config
+//	DELETE /smartqueue/tenant/{id}                      purge the tenant, firing Removed with ?callbacks=true
+//	GET    /smartqueue/tenant/{id}/entry/{key}          show an item
+//	PUT    /smartqueue/tenant/{id}/entry/{key}          enqueue or update an item with a TTL
+//	POST   /smartqueue/tenant/{id}/entry/{key}          extend the TTL of an item
+//	DELETE /smartqueue/tenant/{id}/entry/{key}          remove an item
+//	GET    /smartqueue/tenant/{id}/deadletters          list the dead letters
+//	POST   /smartqueue/tenant/{id}/deadletters/redrive  move the dead letters back into the queue
+//	GET    /metrics                                     the store metrics in the Prometheus text format
+//
+// Listings return one page at a time. They take a limit of up to 1000 (100 by default) and the
+// cursor returned as next_cursor by the previous page. Items are listed in dequeue order, or by
+// soonest expiry with sort=expiry, and min_ttl and max_ttl keep the items whose remaining TTL is in range.
+// Errors come back as a JSON object with an "error" field.
+func (t *tenantTTLStore[K, V]) AdminHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle(tenantsUrl, methodHandlers{
+		http.MethodGet: t.handleTenantIndex,
+	})
+	mux.Handle(tenantSpecificUrl+"{id}", methodHandlers{
+		http.MethodGet:    t.handleTenantView,
+		http.MethodPost:   t.handleTenantDequeue,
+		http.MethodPut:    t.handleTenantConfig,
+		http.MethodDelete: t.handleTenantPurge,
+	})
+	mux.Handle(tenantSpecificUrl+"{id}/entry/{key}", methodHandlers{
+		http.MethodGet:    t.handleTenantEntryView,
+		http.MethodPut:    t.handleTenantEntryEnqueue,
+		http.MethodPost:   t.handleTenantEntryExtend,
+		http.MethodDelete: t.handleTenantEntryRemove,
+	})
+	mux.Handle(tenantSpecificUrl+"{id}/deadletters", methodHandlers{
+		http.MethodGet: func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, t.DeadLetters(r.PathValue("id")))
+		},
+	})
+	mux.Handle(tenantSpecificUrl+"{id}/deadletters/redrive", methodHandlers{
+		http.MethodPost: func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, map[string]int{"redriven": t.Redrive(r.PathValue("id"))})
+		},
+	})
+	mux.Handle(metricsUrl, methodHandlers{
+		http.MethodGet: t.handleMetrics,
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "not found")
+	})
+	return mux
+}
+
+// RegisterHTTPHandlers serves AdminHandler in the background on the given port, or the one set with WithHTTPPort.
+// It returns once the port is listening; Shutdown closes the server.
+func (t *tenantTTLStore[K, V]) RegisterHTTPHandlers(port ...int64) (err error) {
+	httpPort := t.cfg.httpPort
+	if len(port) != 0 {
+		httpPort = port[0]
+	}
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", httpPort))
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{Handler: t.AdminHandler()}
+	t.tenantsMu.Lock()
+	if t.closed {
+		t.tenantsMu.Unlock()
+		_ = listener.Close()
+		return ErrClosed
+	}
+	t.servers = append(t.servers, server)
+	t.tenantsMu.Unlock()
+
+	go func() {
+		if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+			t.cfg.logger.Error("smartqueue: admin server stopped", "error", err)
+		}
+	}()
+	return nil
+}
+
+func (t *tenantTTLStore[K, V]) handleTenantDequeue(w http.ResponseWriter, r *http.Request) {
+	items := t.DequeueN(r.PathValue("id"), 1)
+	if len(items) == 0 {
+		writeError(w, http.StatusNotFound, "no item ready")
+		return
+	}
+
+	writeJSON(w, newTenantView(items[0].Key, items[0].Value, items[0].ExpiresAt, t.cfg.clock.Now()))
+}
+
+func (t *tenantTTLStore[K, V]) handleTenantConfig(w http.ResponseWriter, r *http.Request) {
+	var view tenantConfigView
+	if err := json.NewDecoder(r.Body).Decode(&view); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid body: "+err.Error())
+		return
+	}
+	tenantConfig, err := view.tenantConfig()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tenantId := r.PathValue("id")
+	if err := t.SetTenantConfig(tenantId, tenantConfig); err != nil {
+		writeError(w, errorStatus(err), err.Error())
+		return
+	}
+	tenantConfig, _ = t.GetTenantConfig(tenantId)
+	writeJSON(w, newTenantConfigView(tenantConfig))
+}
+
+func (t *tenantTTLStore[K, V]) handleTenantPurge(w http.ResponseWriter, r *http.Request) {
+	fireCallbacks := false
+	if s := r.URL.Query().Get("callbacks"); s != "" {
+		var err error
+		if fireCallbacks, err = strconv.ParseBool(s); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid callbacks parameter")
+			return
+		}
+	}
+	if !t.DeleteTenant(r.PathValue("id"), fireCallbacks) {
+		writeError(w, http.StatusNotFound, "tenant not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (t *tenantTTLStore[K, V]) handleTenantEntryView(w http.ResponseWriter, r *http.Request) {
+	entryID, ok := entryKey[K](w, r)
+	if ok {
+		t.writeEntry(w, r.PathValue("id"), entryID)
+	}
+}
+
+func (t *tenantTTLStore[K, V]) handleTenantEntryEnqueue(w http.ResponseWriter, r *http.Request) {
+	entryID, ok := entryKey[K](w, r)
+	if !ok {
+		return
+	}
+	req, ttl, ok := decodeEntryRequest[V](w, r)
+	if !ok {
+		return
+	}
+
+	tenantId := r.PathValue("id")
+	// without a positive ttl the item would be stored already expired
+	if ttl <= 0 && t.defaultTTL(tenantId) <= 0 {
+		if req.TTL == "" {
+			writeError(w, http.StatusBadRequest, "ttl required")
+		} else {
+			writeError(w, http.StatusBadRequest, "ttl must be positive")
+		}
+		return
+	}
+	if _, err := t.enqueue(r.Context(), tenantId, entryID, req.Value, req.Priority, time.Time{}, nil, ttl); err != nil {
+		writeError(w, errorStatus(err), err.Error())
+		return
+	}
+	t.writeEntry(w, tenantId, entryID)
+}
+
+// defaultTTL returns the default TTL of the tenant, or the one it would be created with; zero means none.
+func (t *tenantTTLStore[K, V]) defaultTTL(tenantId string) time.Duration {
+	if tenantConfig, ok := t.GetTenantConfig(tenantId); ok {
+		return tenantConfig.DefaultTTL
+	}
+	t.tenantsMu.RLock()
+	defer t.tenantsMu.RUnlock()
+	return t.resolveTenantConfig(t.cfg.tenantConfigs[tenantId]).DefaultTTL
+}
+
+func (t *tenantTTLStore[K, V]) handleTenantEntryExtend(w http.ResponseWriter, r *http.Request) {
+	entryID, ok := entryKey[K](w, r)
+	if !ok {
+		return
+	}
+	req, ttl, ok := decodeEntryRequest[V](w, r)
+	if !ok {
+		return
+	}
+	if req.TTL == "" {
+		writeError(w, http.StatusBadRequest, "ttl required")
+		return
+	}
+	if ttl <= 0 {
+		writeError(w, http.StatusBadRequest, "ttl must be positive")
+		return
+	}
+
+	tenantId := r.PathValue("id")
+	if !t.ExtendTTL(tenantId, entryID, ttl) {
+		writeError(w, http.StatusNotFound, "entry not found")
+		return
+	}
+	t.writeEntry(w, tenantId, entryID)
+}
+
+func (t *tenantTTLStore[K, V]) handleTenantEntryRemove(w http.ResponseWriter, r *http.Request) {
+	entryID, ok := entryKey[K](w, r)
+	if !ok {
+		return
+	}
+	if !t.RemoveBatch(r.PathValue("id"), []K{entryID})[0] {
+		writeError(w, http.StatusNotFound, "entry not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeEntry writes the view of one item, or a not found error.
+func (t *tenantTTLStore[K, V]) writeEntry(w http.ResponseWriter, tenantId string, entryID K) {
+	tenantSpecificOrderedStore, ok := t.GetTenantOrderedMap(tenantId)
+	if !ok {
+		writeError(w, http.StatusNotFound, "tenant not found")
+		return
+	}
+
+	tenantSpecificOrderedStore.mu.RLock()
+	e, ok := tenantSpecificOrderedStore.entryMap[entryID]
+	var view tenantView[K, V]
+	if ok {
+		view = newTenantView(e.id, e.value, e.expiryTime, t.cfg.clock.Now())
+	}
+	tenantSpecificOrderedStore.mu.RUnlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "entry not found")
+		return
+	}
+	writeJSON(w, view)
+}
+
+// entryKey parses the {key} path value, writing an error when it is not a valid K.
+func entryKey[K comparable](w http.ResponseWriter, r *http.Request) (K, bool) {
+	entryID, err := parseKey[K](r.PathValue("key"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid entry ID")
+		return entryID, false
+	}
+	return entryID, true
+}
+
+// decodeEntryRequest reads an entryRequest body, writing an error when it or its TTL is invalid.
+func decodeEntryRequest[V any](w http.ResponseWriter, r *http.Request) (req entryRequest[V], ttl time.Duration, ok bool) {
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid body: "+err.Error())
+		return req, ttl, false
+	}
+	if req.TTL != "" {
+		var err error
+		if ttl, err = time.ParseDuration(req.TTL); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid ttl")
+			return req, ttl, false
+		}
+	}
+	return req, ttl, true
+}
+
+func newTenantConfigView(tenantConfig TenantConfig) tenantConfigView {
+	return tenantConfigView{
+		Capacity:       tenantConfig.Capacity,
+		DefaultTTL:     tenantConfig.DefaultTTL.String(),
+		MaxTTL:         tenantConfig.MaxTTL.String(),
+		EvictionPolicy: tenantConfig.EvictionPolicy.String(),
+		Weight:         tenantConfig.Weight,
+	}
+}
+
+// tenantConfig converts the view back; empty fields stay zero and fall back to the store-wide settings.
+func (v tenantConfigView) tenantConfig() (TenantConfig, error) {
+	tenantConfig := TenantConfig{Capacity: v.Capacity, Weight: v.Weight}
+	var err error
+	if v.DefaultTTL != "" {
+		if tenantConfig.DefaultTTL, err = time.ParseDuration(v.DefaultTTL); err != nil {
+			return tenantConfig, errors.New("invalid default_ttl")
+		}
+	}
+	if v.MaxTTL != "" {
+		if tenantConfig.MaxTTL, err = time.ParseDuration(v.MaxTTL); err != nil {
+			return tenantConfig, errors.New("invalid max_ttl")
+		}
+	}
+	if v.EvictionPolicy != "" {
+		for policy := EvictOldest; policy <= Block; policy++ {
+			if policy.String() == v.EvictionPolicy {
+				tenantConfig.EvictionPolicy = policy
+			}
+		}
+		if tenantConfig.EvictionPolicy == 0 {
+			return tenantConfig, errors.New("invalid eviction_policy")
+		}
+	}
+	return tenantConfig, nil
+}
+
+// errorStatus maps a store error to an HTTP status code.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrInvalidTenantConfig):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrCapacityReached):
+		return http.StatusConflict
+	case errors.Is(err, ErrClosed), errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// parseKey converts a path segment into a key of type K. The whole segment must parse.
+func parseKey[K comparable](s string) (K, error) {
+	var key K
+	v := reflect.ValueOf(&key).Elem()
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return key, err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return key, err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return key, err
+		}
+		v.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return key, err
+		}
+		v.SetBool(b)
+	default:
+		// the trailing newline makes Sscanf fail on input left unread
+		if _, err := fmt.Sscanf(s+"\n", "%v\n", &key); err != nil {
+			return key, err
+		}
+	}
+	return key, nil
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(errorView{Error: message})
+}
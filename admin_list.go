@@ -0,0 +1,429 @@
+package smartqueue
+
+import (
+	"cmp"
+	"container/list"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const (
+	tenantsUrl       = `/smartqueue/tenants`
+	defaultPageLimit = 100
+	maxPageLimit     = 1000
+)
+
+// listQuery holds the paging, sorting and filter parameters of a listing.
+type listQuery struct {
+	limit  int
+	cursor string
+	// byExpiry sorts by soonest expiry instead of dequeue order
+	byExpiry bool
+	minTTL   time.Duration
+	// maxTTL is negative when there is no upper bound
+	maxTTL time.Duration
+}
+
+type tenantPage[K comparable, V any] struct {
+	Items      []tenantView[K, V] `json:"items"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+}
+
+type tenantIndexView struct {
+	Tenant      string `json:"tenant"`
+	Len         int    `json:"len"`
+	Capacity    int64  `json:"capacity"`
+	Ready       int    `json:"ready"`
+	Leased      int    `json:"leased"`
+	Delayed     int    `json:"delayed"`
+	DeadLetters int    `json:"dead_letters"`
+}
+
+type tenantIndexPage struct {
+	Tenants    []tenantIndexView `json:"tenants"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
+
+// parseListQuery reads limit, cursor, sort, min_ttl and max_ttl from the query string.
+func parseListQuery(r *http.Request) (listQuery, error) {
+	query := r.URL.Query()
+	q := listQuery{limit: defaultPageLimit, cursor: query.Get("cursor"), maxTTL: -1}
+
+	if s := query.Get("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit <= 0 || limit > maxPageLimit {
+			return q, fmt.Errorf("limit must be between 1 and %d", maxPageLimit)
+		}
+		q.limit = limit
+	}
+	switch query.Get("sort") {
+	case "", "fifo":
+	case "expiry":
+		q.byExpiry = true
+	default:
+		return q, errors.New("sort must be fifo or expiry")
+	}
+	if s := query.Get("min_ttl"); s != "" {
+		minTTL, err := time.ParseDuration(s)
+		if err != nil {
+			return q, errors.New("invalid min_ttl")
+		}
+		q.minTTL = minTTL
+	}
+	if s := query.Get("max_ttl"); s != "" {
+		maxTTL, err := time.ParseDuration(s)
+		if err != nil || maxTTL < 0 {
+			return q, errors.New("invalid max_ttl")
+		}
+		q.maxTTL = maxTTL
+	}
+	return q, nil
+}
+
+// keep reports whether an item with the given remaining TTL passes the filters.
+// Items without expiry pass any min_ttl and fail any max_ttl.
+func (q listQuery) keep(ttl time.Duration) bool {
+	if ttl == NoExpiry {
+		return q.maxTTL < 0
+	}
+	return ttl >= q.minTTL && (q.maxTTL < 0 || ttl <= q.maxTTL)
+}
+
+// listCursor is the sort position of the last listed item. A listing resumes behind that
+// position, so items are neither repeated nor skipped when the item itself has left the tenant.
+type listCursor[K comparable] struct {
+	Key      K    `json:"k"`
+	ByExpiry bool `json:"x,omitempty"`
+	// Stage tells in dequeue order whether the item was ready, leased or delayed
+	Stage    listStage `json:"g,omitempty"`
+	Priority Priority  `json:"p,omitempty"`
+	Seq      int64     `json:"s,omitempty"`
+	// At is the expiry, lease deadline or ready time the item is sorted by; a zero expiry sorts last
+	At time.Time `json:"t,omitzero"`
+}
+
+// listStage is a part of the dequeue order: ready items come first, then leased ones, then delayed ones.
+type listStage int
+
+const (
+	stageReady listStage = iota
+	stageLeased
+	stageDelayed
+)
+
+func newListCursor[K comparable, V any](e *entry[K, V], byExpiry bool) listCursor[K] {
+	switch {
+	case byExpiry:
+		return listCursor[K]{Key: e.id, ByExpiry: true, At: e.expiryTime}
+	case e.leased():
+		return listCursor[K]{Key: e.id, Stage: stageLeased, At: e.leaseDeadline}
+	case e.delayed():
+		return listCursor[K]{Key: e.id, Stage: stageDelayed, At: e.readyAt}
+	default:
+		return listCursor[K]{Key: e.id, Priority: e.priority, Seq: e.seq}
+	}
+}
+
+// handleTenantView lists one page of the unexpired items of a tenant. In dequeue order, ready
+// items come first, followed by leased items by lease deadline and delayed items by ready time.
+func (t *tenantTTLStore[K, V]) handleTenantView(w http.ResponseWriter, r *http.Request) {
+	q, err := parseListQuery(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var after *listCursor[K]
+	if q.cursor != "" {
+		cursor, err := decodeCursor[listCursor[K]](q.cursor)
+		if err != nil || cursor.ByExpiry != q.byExpiry {
+			writeError(w, http.StatusBadRequest, "invalid cursor")
+			return
+		}
+		after = &cursor
+	}
+
+	tenantStore, ok := t.GetTenantOrderedMap(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "tenant not found")
+		return
+	}
+
+	// encoded once the tenant lock is released, so a slow client does not hold up the tenant
+	writeJSON(w, t.tenantPage(tenantStore, q, after))
+}
+
+// tenantPage collects the page behind after under the tenant lock.
+func (t *tenantTTLStore[K, V]) tenantPage(tenantStore *orderedStore[K, V], q listQuery, after *listCursor[K]) tenantPage[K, V] {
+	tenantStore.mu.RLock()
+	defer tenantStore.mu.RUnlock()
+
+	now := t.cfg.clock.Now()
+	keep := func(e *entry[K, V]) bool {
+		return !e.expired(now) && q.keep(e.remaining(now))
+	}
+	listed := t.fifoEntries(tenantStore, after)
+	if q.byExpiry {
+		listed = t.expiryEntries(tenantStore, after)
+	}
+	// one more entry than the limit tells whether there is a next page
+	var entries []*entry[K, V]
+	for e := range listed {
+		if !keep(e) {
+			continue
+		}
+		if entries = append(entries, e); len(entries) > q.limit {
+			break
+		}
+	}
+
+	var page tenantPage[K, V]
+	if len(entries) > q.limit {
+		entries = entries[:q.limit]
+		page.NextCursor = encodeCursor(newListCursor(entries[len(entries)-1], q.byExpiry))
+	}
+	page.Items = make([]tenantView[K, V], 0, len(entries))
+	for _, e := range entries {
+		page.Items = append(page.Items, newTenantView(e.id, e.value, e.expiryTime, now))
+	}
+	return page
+}
+
+// fifoEntries yields the entries behind after in dequeue order. Ready entries resume from the order
+// index by priority and sequence number, and hidden ones from the expiry and ready heaps.
+// Caller must hold tenantSpecificOrderedStore.mu
+func (t *tenantTTLStore[K, V]) fifoEntries(tenantSpecificOrderedStore *orderedStore[K, V], after *listCursor[K]) iter.Seq[*entry[K, V]] {
+	return func(yield func(*entry[K, V]) bool) {
+		stage := stageReady
+		if after != nil {
+			stage = after.Stage
+		}
+		// from returns the cursor when the listing resumes within the given stage
+		from := func(s listStage) *listCursor[K] {
+			if s == stage {
+				return after
+			}
+			return nil
+		}
+
+		if stage == stageReady {
+			for _, l := range tenantSpecificOrderedStore.order.levels {
+				start := l.items.Front()
+				if after != nil {
+					if l.priority > after.Priority {
+						continue
+					}
+					if l.priority == after.Priority {
+						start = t.behind(tenantSpecificOrderedStore, l.items, after)
+					}
+				}
+				for elem := start; elem != nil; elem = elem.Next() {
+					if !yield(tenantSpecificOrderedStore.entryMap[elem.Value.(K)]) {
+						return
+					}
+				}
+			}
+		}
+
+		// leased and delayed items are out of the order list
+		if stage <= stageLeased {
+			leased := heapEntries(tenantSpecificOrderedStore, tenantSpecificOrderedStore.expiryListHeap, from(stageLeased),
+				func(e *entry[K, V], item *expiry[K]) bool { return e.leaseItem == item })
+			for e := range leased {
+				if !yield(e) {
+					return
+				}
+			}
+		}
+		delayed := heapEntries(tenantSpecificOrderedStore, tenantSpecificOrderedStore.readyListHeap, from(stageDelayed),
+			func(e *entry[K, V], item *expiry[K]) bool { return true })
+		for e := range delayed {
+			if !yield(e) {
+				return
+			}
+		}
+	}
+}
+
+// behind returns the first element of items whose sequence number is past the cursor.
+// Caller must hold tenantSpecificOrderedStore.mu
+func (t *tenantTTLStore[K, V]) behind(tenantSpecificOrderedStore *orderedStore[K, V], items *list.List, after *listCursor[K]) *list.Element {
+	// the cursor item usually still sits where it was listed
+	if e, ok := tenantSpecificOrderedStore.entryMap[after.Key]; ok && e.element != nil &&
+		e.priority == after.Priority && e.seq == after.Seq {
+		return e.element.Next()
+	}
+	elem := items.Front()
+	for elem != nil && tenantSpecificOrderedStore.entryMap[elem.Value.(K)].seq <= after.Seq {
+		elem = elem.Next()
+	}
+	return elem
+}
+
+// expiryEntries yields the entries behind after by soonest expiry, then by key.
+// Expiring entries are walked from the top of the expiry heap, so a page visits the entries before the
+// cursor but never sorts the tenant. Persisted entries sort last and are collected by a scan of the
+// tenant, which only the pages past every expiring entry pay for.
+// Caller must hold tenantSpecificOrderedStore.mu
+func (t *tenantTTLStore[K, V]) expiryEntries(tenantSpecificOrderedStore *orderedStore[K, V], after *listCursor[K]) iter.Seq[*entry[K, V]] {
+	return func(yield func(*entry[K, V]) bool) {
+		if after == nil || !after.At.IsZero() {
+			expiring := heapEntries(tenantSpecificOrderedStore, tenantSpecificOrderedStore.expiryListHeap, after,
+				func(e *entry[K, V], item *expiry[K]) bool { return e.ttlItem == item })
+			for e := range expiring {
+				if !yield(e) {
+					return
+				}
+			}
+		}
+
+		var persisted []*entry[K, V]
+		for _, e := range tenantSpecificOrderedStore.entryMap {
+			if e.expiryTime.IsZero() && (after == nil || !after.At.IsZero() || compareKeys(e.id, after.Key) > 0) {
+				persisted = append(persisted, e)
+			}
+		}
+		slices.SortFunc(persisted, func(a, b *entry[K, V]) int { return compareKeys(a.id, b.id) })
+		for _, e := range persisted {
+			if !yield(e) {
+				return
+			}
+		}
+	}
+}
+
+// heapEntries yields the entries of the heap items that match, by heap time and then by key,
+// behind after when it is set. Items of the same time are collected before they are yielded,
+// since the heap does not order them by key.
+// Caller must hold tenantSpecificOrderedStore.mu
+func heapEntries[K comparable, V any](tenantSpecificOrderedStore *orderedStore[K, V], items expiryList[K], after *listCursor[K],
+	match func(e *entry[K, V], item *expiry[K]) bool) iter.Seq[*entry[K, V]] {
+
+	return func(yield func(*entry[K, V]) bool) {
+		var from time.Time
+		if after != nil {
+			from = after.At
+		}
+		var group []*entry[K, V]
+		var at time.Time
+		flush := func() bool {
+			slices.SortFunc(group, func(a, b *entry[K, V]) int { return compareKeys(a.id, b.id) })
+			for _, e := range group {
+				if after != nil && at.Equal(after.At) && compareKeys(e.id, after.Key) <= 0 {
+					continue
+				}
+				if !yield(e) {
+					return false
+				}
+			}
+			group = group[:0]
+			return true
+		}
+
+		for item := range items.ascending(from) {
+			e := tenantSpecificOrderedStore.entryMap[item.key]
+			if !match(e, item) {
+				continue
+			}
+			if len(group) > 0 && !item.expiration.Equal(at) && !flush() {
+				return
+			}
+			at = item.expiration
+			group = append(group, e)
+		}
+		flush()
+	}
+}
+
+// handleTenantIndex lists one page of the tenants, in sorted order, with their counts.
+func (t *tenantTTLStore[K, V]) handleTenantIndex(w http.ResponseWriter, r *http.Request) {
+	q, err := parseListQuery(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tenantIds := t.Tenants()
+	if q.cursor != "" {
+		after, err := decodeCursor[string](q.cursor)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid cursor")
+			return
+		}
+		i, found := slices.BinarySearch(tenantIds, after)
+		if found {
+			i++
+		}
+		tenantIds = tenantIds[i:]
+	}
+
+	page := tenantIndexPage{Tenants: make([]tenantIndexView, 0, min(q.limit, len(tenantIds)))}
+	for _, tenantId := range tenantIds {
+		stats, ok := t.Stats(tenantId)
+		if !ok {
+			// deleted since Tenants
+			continue
+		}
+		if len(page.Tenants) == q.limit {
+			page.NextCursor = encodeCursor(page.Tenants[len(page.Tenants)-1].Tenant)
+			break
+		}
+		page.Tenants = append(page.Tenants, tenantIndexView{
+			Tenant:      tenantId,
+			Len:         stats.Len,
+			Capacity:    stats.Capacity,
+			Ready:       stats.Ready,
+			Leased:      stats.Leased,
+			Delayed:     stats.Delayed,
+			DeadLetters: stats.DeadLetters,
+		})
+	}
+
+	writeJSON(w, page)
+}
+
+// encodeCursor turns the position of the last listed item into an opaque cursor.
+func encodeCursor[T any](position T) string {
+	b, _ := json.Marshal(position)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor[T any](cursor string) (T, error) {
+	var position T
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return position, err
+	}
+	err = json.Unmarshal(b, &position)
+	return position, err
+}
+
+// compareKeys orders keys to break ties in a stable way: ordered keys by value,
+// and other keys by their printed form.
+func compareKeys[K comparable](a, b K) int {
+	switch a := any(a).(type) {
+	case string:
+		return cmp.Compare(a, any(b).(string))
+	case int:
+		return cmp.Compare(a, any(b).(int))
+	case int64:
+		return cmp.Compare(a, any(b).(int64))
+	case int32:
+		return cmp.Compare(a, any(b).(int32))
+	case uint:
+		return cmp.Compare(a, any(b).(uint))
+	case uint64:
+		return cmp.Compare(a, any(b).(uint64))
+	case uint32:
+		return cmp.Compare(a, any(b).(uint32))
+	case float64:
+		return cmp.Compare(a, any(b).(float64))
+	}
+	return cmp.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
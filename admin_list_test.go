@@ -0,0 +1,232 @@
+package smartqueue
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/smartqueue/smartqueuetest"
+)
+
+// listPages follows next_cursor from the given listing URL and returns every page.
+func listPages[P any](t *testing.T, handler http.Handler, url string, cursor func(P) string) []P {
+	t.Helper()
+	var pages []P
+	next := ""
+	for {
+		target := url
+		if next != "" {
+			target += "&cursor=" + next
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d: %s", target, rec.Code, rec.Body)
+		}
+		var page P
+		if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+			t.Fatalf("%s: %v", target, err)
+		}
+		pages = append(pages, page)
+		if next = cursor(page); next == "" || len(pages) > 10 {
+			return pages
+		}
+	}
+}
+
+func TestAdminTenantListing(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewTenantStore[int64, string](WithClock(smartqueuetest.NewFakeClock(now)))
+	defer store.Stop()
+
+	store.Enqueue("t0001", 1, "one", nil, 5*time.Minute)
+	store.Enqueue("t0001", 2, "two", nil, time.Minute)
+	store.Enqueue("t0001", 3, "three", nil, 3*time.Minute)
+	store.Enqueue("t0001", 4, "four", nil, 4*time.Minute)
+	store.Persist("t0001", 4)
+	if _, err := store.EnqueueWithPriority("t0001", 5, "five", 1, nil, 2*time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	// 6 is delayed, so it is listed after the ready items
+	if _, err := store.EnqueueAfter("t0001", 6, "six", time.Minute, nil, 10*time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		wantKeys []int64
+	}{
+		{name: "Dequeue order", query: "limit=2", wantKeys: []int64{5, 1, 2, 3, 4, 6}},
+		{name: "Expiry order", query: "limit=2&sort=expiry", wantKeys: []int64{2, 5, 3, 1, 6, 4}},
+		{name: "TTL range", query: "limit=2&min_ttl=2m&max_ttl=5m", wantKeys: []int64{5, 1, 3}},
+		{name: "Minimum TTL", query: "limit=1&min_ttl=10m", wantKeys: []int64{4, 6}},
+		{name: "Maximum TTL by expiry", query: "sort=expiry&max_ttl=3m", wantKeys: []int64{2, 5, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pages := listPages(t, store.AdminHandler(), "/smartqueue/tenant/t0001?"+tt.query,
+				func(page tenantPage[int64, string]) string { return page.NextCursor })
+
+			var keys []int64
+			for _, page := range pages {
+				for _, item := range page.Items {
+					keys = append(keys, item.Key)
+				}
+			}
+			if !slices.Equal(keys, tt.wantKeys) {
+				t.Errorf("%s: expected keys %v, got %v", tt.name, tt.wantKeys, keys)
+			}
+			if last := pages[len(pages)-1]; last.NextCursor != "" {
+				t.Errorf("%s: expected no cursor on the last page, got %q", tt.name, last.NextCursor)
+			}
+		})
+	}
+}
+
+func TestAdminTenantListingCursor(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		remove []int64
+		want   []int64
+	}{
+		{name: "Dequeue order, first page consumed", query: "limit=2", remove: []int64{1, 2}, want: []int64{3, 4}},
+		{name: "Dequeue order, cursor item removed", query: "limit=2", remove: []int64{2}, want: []int64{3, 4}},
+		{name: "Expiry order, cursor item removed", query: "limit=2&sort=expiry", remove: []int64{4}, want: []int64{3, 2}},
+		{name: "Expiry order, next item removed", query: "limit=2&sort=expiry", remove: []int64{3}, want: []int64{2, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewTenantStore[int64, string](WithClock(smartqueuetest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))))
+			defer store.Stop()
+			// later keys expire sooner
+			for i := int64(1); i <= 5; i++ {
+				store.Enqueue("t0001", i, "item", nil, time.Duration(10-i)*time.Minute)
+			}
+			handler := store.AdminHandler()
+
+			first := listPage(t, handler, "/smartqueue/tenant/t0001?"+tt.query)
+			// consumers took items while the first page was being read
+			store.RemoveBatch("t0001", tt.remove)
+			second := listPage(t, handler, "/smartqueue/tenant/t0001?"+tt.query+"&cursor="+first.NextCursor)
+
+			var keys []int64
+			for _, item := range second.Items {
+				keys = append(keys, item.Key)
+			}
+			if !slices.Equal(keys, tt.want) {
+				t.Errorf("%s: expected the listing to resume with %v, got %v", tt.name, tt.want, keys)
+			}
+		})
+	}
+}
+
+func TestAdminTenantListingTies(t *testing.T) {
+	store := NewTenantStore[int64, string](WithClock(smartqueuetest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))))
+	defer store.Stop()
+
+	// every ready item expires at the same time, so ties are broken by key
+	for _, key := range []int64{6, 5, 4, 3, 2, 1} {
+		store.Enqueue("t0001", key, "item", nil, time.Minute)
+	}
+	// 6 and 5 are leased, 5 with the earlier deadline
+	store.Lease("t0001", 2*time.Minute)
+	store.Lease("t0001", time.Minute)
+	// 8 becomes ready first, and also expires first
+	store.EnqueueAfter("t0001", 7, "item", 2*time.Minute, nil, time.Minute)
+	store.EnqueueAfter("t0001", 8, "item", time.Minute, nil, time.Minute)
+
+	tests := []struct {
+		name     string
+		query    string
+		wantKeys []int64
+	}{
+		{name: "Dequeue order", query: "limit=1", wantKeys: []int64{4, 3, 2, 1, 5, 6, 8, 7}},
+		{name: "Expiry order", query: "limit=1&sort=expiry", wantKeys: []int64{1, 2, 3, 4, 5, 6, 8, 7}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pages := listPages(t, store.AdminHandler(), "/smartqueue/tenant/t0001?"+tt.query,
+				func(page tenantPage[int64, string]) string { return page.NextCursor })
+			var keys []int64
+			for _, page := range pages {
+				for _, item := range page.Items {
+					keys = append(keys, item.Key)
+				}
+			}
+			if !slices.Equal(keys, tt.wantKeys) {
+				t.Errorf("%s: expected keys %v, got %v", tt.name, tt.wantKeys, keys)
+			}
+		})
+	}
+}
+
+func TestAdminTenantListingInvalid(t *testing.T) {
+	store := NewTenantStore[int64, string]()
+	defer store.Stop()
+	for i := int64(1); i <= 3; i++ {
+		store.Enqueue("t0001", i, "item", nil, time.Minute)
+	}
+	handler := store.AdminHandler()
+	fifoCursor := listPage(t, handler, "/smartqueue/tenant/t0001?limit=1").NextCursor
+
+	queries := []string{"limit=0", "limit=1001", "sort=size", "min_ttl=soon", "max_ttl=-1s", "cursor=!",
+		"sort=expiry&cursor=" + fifoCursor}
+	for _, query := range queries {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/smartqueue/tenant/t0001?"+query, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", query, rec.Code)
+		}
+	}
+}
+
+// listPage gets one page of a tenant listing.
+func listPage(t *testing.T, handler http.Handler, url string) tenantPage[int64, string] {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+	var page tenantPage[int64, string]
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("%s: unexpected response %d: %s", url, rec.Code, rec.Body)
+	}
+	return page
+}
+
+func TestAdminTenantIndex(t *testing.T) {
+	store := NewTenantStore[int64, string]()
+	defer store.Stop()
+
+	store.Enqueue("t0003", 1, "one", nil, time.Minute)
+	store.Enqueue("t0001", 1, "one", nil, time.Minute)
+	store.Enqueue("t0001", 2, "two", nil, time.Minute)
+	store.Lease("t0001", time.Minute)
+	if err := store.CreateTenant("t0002", TenantConfig{Capacity: 7}); err != nil {
+		t.Fatal(err)
+	}
+
+	pages := listPages(t, store.AdminHandler(), "/smartqueue/tenants?limit=2",
+		func(page tenantIndexPage) string { return page.NextCursor })
+	if len(pages) != 2 {
+		t.Fatalf("expected 2 pages, got %d", len(pages))
+	}
+
+	var tenants []tenantIndexView
+	for _, page := range pages {
+		tenants = append(tenants, page.Tenants...)
+	}
+	want := []tenantIndexView{
+		{Tenant: "t0001", Len: 2, Capacity: defaultCapacity, Ready: 1, Leased: 1},
+		{Tenant: "t0002", Capacity: 7},
+		{Tenant: "t0003", Len: 1, Capacity: defaultCapacity, Ready: 1},
+	}
+	if !slices.Equal(tenants, want) {
+		t.Errorf("expected %+v, got %+v", want, tenants)
+	}
+}
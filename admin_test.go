@@ -0,0 +1,134 @@
+package smartqueue
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/smartqueue/smartqueuetest"
+)
+
+func TestAdminHandler(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "Show tenant", method: http.MethodGet, path: "/smartqueue/tenant/t0001", wantStatus: http.StatusOK, wantBody: `"value": "one"`},
+		{name: "Unknown tenant", method: http.MethodGet, path: "/smartqueue/tenant/t0009", wantStatus: http.StatusNotFound, wantBody: `"error":"tenant not found"`},
+		{name: "Dequeue", method: http.MethodPost, path: "/smartqueue/tenant/t0001", wantStatus: http.StatusOK, wantBody: `"key": 1`},
+		{name: "Dequeue empty tenant", method: http.MethodPost, path: "/smartqueue/tenant/t0002", wantStatus: http.StatusNotFound, wantBody: `"error":"no item ready"`},
+		{name: "Set config", method: http.MethodPut, path: "/smartqueue/tenant/t0001", body: `{"capacity": 5, "eviction_policy": "reject_new"}`, wantStatus: http.StatusOK, wantBody: `"eviction_policy": "reject_new"`},
+		{name: "Invalid config", method: http.MethodPut, path: "/smartqueue/tenant/t0001", body: `{"capacity": -1}`, wantStatus: http.StatusBadRequest, wantBody: ErrInvalidTenantConfig.Error()},
+		{name: "Unknown policy", method: http.MethodPut, path: "/smartqueue/tenant/t0001", body: `{"eviction_policy": "never"}`, wantStatus: http.StatusBadRequest, wantBody: `"error":"invalid eviction_policy"`},
+		{name: "Purge", method: http.MethodDelete, path: "/smartqueue/tenant/t0001", wantStatus: http.StatusNoContent},
+		{name: "Purge unknown tenant", method: http.MethodDelete, path: "/smartqueue/tenant/t0009", wantStatus: http.StatusNotFound, wantBody: `"error":"tenant not found"`},
+		{name: "Show entry", method: http.MethodGet, path: "/smartqueue/tenant/t0001/entry/2", wantStatus: http.StatusOK, wantBody: `"ttl_remaining": 60000000000`},
+		{name: "Show persisted entry", method: http.MethodGet, path: "/smartqueue/tenant/t0001/entry/4", wantStatus: http.StatusOK, wantBody: `"expiry_time": 0,`},
+		{name: "Invalid entry ID", method: http.MethodGet, path: "/smartqueue/tenant/t0001/entry/abc", wantStatus: http.StatusBadRequest, wantBody: `"error":"invalid entry ID"`},
+		{name: "Enqueue", method: http.MethodPut, path: "/smartqueue/tenant/t0001/entry/3", body: `{"value": "three", "ttl": "30s"}`, wantStatus: http.StatusOK, wantBody: `"ttl_remaining": 30000000000`},
+		{name: "Enqueue into a full tenant", method: http.MethodPut, path: "/smartqueue/tenant/t0003/entry/2", body: `{"value": "two", "ttl": "1m"}`, wantStatus: http.StatusConflict, wantBody: ErrCapacityReached.Error()},
+		{name: "Enqueue without TTL", method: http.MethodPut, path: "/smartqueue/tenant/t0001/entry/3", body: `{"value": "three"}`, wantStatus: http.StatusBadRequest, wantBody: `"error":"ttl required"`},
+		{name: "Enqueue with a negative TTL", method: http.MethodPut, path: "/smartqueue/tenant/t0001/entry/3", body: `{"value": "three", "ttl": "-1s"}`, wantStatus: http.StatusBadRequest, wantBody: `"error":"ttl must be positive"`},
+		{name: "Enqueue with the tenant default TTL", method: http.MethodPut, path: "/smartqueue/tenant/t0004/entry/1", body: `{"value": "one"}`, wantStatus: http.StatusOK, wantBody: `"ttl_remaining": 60000000000`},
+		{name: "Invalid TTL", method: http.MethodPut, path: "/smartqueue/tenant/t0001/entry/3", body: `{"ttl": "soon"}`, wantStatus: http.StatusBadRequest, wantBody: `"error":"invalid ttl"`},
+		{name: "Invalid body", method: http.MethodPut, path: "/smartqueue/tenant/t0001/entry/3", body: `{`, wantStatus: http.StatusBadRequest, wantBody: `invalid body`},
+		{name: "Extend TTL", method: http.MethodPost, path: "/smartqueue/tenant/t0001/entry/2", body: `{"ttl": "1m"}`, wantStatus: http.StatusOK, wantBody: `"ttl_remaining": 120000000000`},
+		{name: "Extend without TTL", method: http.MethodPost, path: "/smartqueue/tenant/t0001/entry/2", body: `{}`, wantStatus: http.StatusBadRequest, wantBody: `"error":"ttl required"`},
+		{name: "Extend with a negative TTL", method: http.MethodPost, path: "/smartqueue/tenant/t0001/entry/2", body: `{"ttl": "-30s"}`, wantStatus: http.StatusBadRequest, wantBody: `"error":"ttl must be positive"`},
+		{name: "Extend unknown entry", method: http.MethodPost, path: "/smartqueue/tenant/t0001/entry/9", body: `{"ttl": "1m"}`, wantStatus: http.StatusNotFound, wantBody: `"error":"entry not found"`},
+		{name: "Remove", method: http.MethodDelete, path: "/smartqueue/tenant/t0001/entry/2", wantStatus: http.StatusNoContent},
+		{name: "Remove unknown entry", method: http.MethodDelete, path: "/smartqueue/tenant/t0001/entry/9", wantStatus: http.StatusNotFound, wantBody: `"error":"entry not found"`},
+		{name: "Dead letters", method: http.MethodGet, path: "/smartqueue/tenant/t0001/deadletters", wantStatus: http.StatusOK, wantBody: `null`},
+		{name: "Redrive", method: http.MethodPost, path: "/smartqueue/tenant/t0001/deadletters/redrive", wantStatus: http.StatusOK, wantBody: `"redriven": 0`},
+		{name: "Method not allowed", method: http.MethodPatch, path: "/smartqueue/tenant/t0001", wantStatus: http.StatusMethodNotAllowed, wantBody: `"error":"method not allowed"`},
+		{name: "Unknown path", method: http.MethodGet, path: "/smartqueue/unknown", wantStatus: http.StatusNotFound, wantBody: `"error":"not found"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewTenantStore[int64, string](WithClock(smartqueuetest.NewFakeClock(now)),
+				WithTenantConfig("t0003", TenantConfig{Capacity: 1, EvictionPolicy: RejectNew}),
+				WithTenantConfig("t0004", TenantConfig{DefaultTTL: time.Minute}))
+			defer store.Stop()
+
+			store.Enqueue("t0001", 1, "one", nil, time.Minute)
+			store.Enqueue("t0001", 2, "two", nil, time.Minute)
+			store.Enqueue("t0001", 4, "four", nil, time.Minute)
+			store.Persist("t0001", 4)
+			if err := store.CreateTenant("t0002"); err != nil {
+				t.Fatalf("%s: unexpected error %v", tt.name, err)
+			}
+			store.Enqueue("t0003", 1, "one", nil, time.Minute)
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			store.AdminHandler().ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("%s: expected status %d, got %d: %s", tt.name, tt.wantStatus, rec.Code, rec.Body)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("%s: expected body to contain %s, got %s", tt.name, tt.wantBody, rec.Body)
+			}
+			if rec.Code >= http.StatusBadRequest {
+				var body errorView
+				if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.Error == "" {
+					t.Errorf("%s: expected a JSON error, got %s", tt.name, rec.Body)
+				}
+			}
+		})
+	}
+}
+
+func TestRegisterHTTPHandlers(t *testing.T) {
+	busy, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer busy.Close()
+	port := int64(busy.Addr().(*net.TCPAddr).Port)
+	url := fmt.Sprintf("http://localhost:%d/smartqueue/tenant/t0001", port)
+
+	store := NewTenantStore[int64, string]()
+	if err := store.RegisterHTTPHandlers(port); err == nil {
+		t.Errorf("expected an error for a port in use")
+	}
+	busy.Close()
+
+	done := make(chan error, 1)
+	go func() { done <- store.RegisterHTTPHandlers(port) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected RegisterHTTPHandlers to return while serving")
+	}
+
+	store.Enqueue("t0001", 1, "one", nil, time.Minute)
+	resp, err := http.Get(url + "/entry/1")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	store.Stop()
+	if _, err := http.Get(url); err == nil {
+		t.Errorf("expected the server to close with the store")
+	}
+}
@@ -0,0 +1,156 @@
+package smartqueue
+
+import (
+	"container/heap"
+	"context"
+	"log/slog"
+	"time"
+)
+
+// BatchItem is one item of an EnqueueBatch call.
+type BatchItem[K comparable, V any] struct {
+	Key      K
+	Value    V
+	Callback Callback[K, V]
+	TTL      time.Duration
+	Priority Priority
+}
+
+// EnqueueResult reports the outcome of one item of an EnqueueBatch call.
+type EnqueueResult struct {
+	CapacityReached bool
+	Err             error
+}
+
+// Item is a key and value taken out of a tenant queue.
+type Item[K comparable, V any] struct {
+	Key       K
+	Value     V
+	ExpiresAt time.Time
+}
+
+// EnqueueBatch inserts or updates several items of one tenant under a single lock and heap fix-up.
+// It never blocks: under the Block policy items that do not fit are rejected with ErrCapacityReached.
+func (t *tenantTTLStore[K, V]) EnqueueBatch(tenantId string, items []BatchItem[K, V]) []EnqueueResult {
+	results := make([]EnqueueResult, len(items))
+	if tracer := t.cfg.tracer; tracer != nil {
+		_, end := tracer.Start(context.Background(), SpanEnqueueBatch, slog.String(AttrTenant, tenantId), slog.Int(AttrCount, len(items)))
+		defer func() { end(enqueuedAttrs(results)...) }()
+	}
+	if len(items) == 0 {
+		return results
+	}
+
+	tenantSpecificOrderedStore := t.lockTenantStore(tenantId)
+	if tenantSpecificOrderedStore == nil {
+		for i := range results {
+			results[i].Err = ErrClosed
+		}
+		return results
+	}
+	defer t.unlock(tenantSpecificOrderedStore)
+
+	now := t.cfg.clock.Now()
+	var earliest time.Time
+	dirty := false
+	for i, item := range items {
+		if _, ok := tenantSpecificOrderedStore.entryMap[item.Key]; !ok &&
+			tenantSpecificOrderedStore.size.Load() >= tenantSpecificOrderedStore.capacity {
+
+			results[i].CapacityReached = true
+			if policy := tenantSpecificOrderedStore.evictionPolicy; policy == RejectNew || policy == Block {
+				results[i].Err = ErrCapacityReached
+				continue
+			}
+			if dirty {
+				// eviction removes heap items, which needs a valid heap
+				heap.Init(&tenantSpecificOrderedStore.expiryListHeap)
+				dirty = false
+			}
+			if !t.evict(tenantId, tenantSpecificOrderedStore) {
+				results[i].Err = ErrCapacityReached
+				continue
+			}
+		}
+
+		exp := t.putLocked(tenantSpecificOrderedStore, item.Key, item.Value, item.Priority, time.Time{}, item.Callback, item.TTL, now)
+		// heap items are added or moved without fixing the heap; heap.Init below restores it once
+		if e := tenantSpecificOrderedStore.entryMap[item.Key]; e.ttlItem != nil {
+			e.ttlItem.expiration = exp
+		} else {
+			e.ttlItem = &expiry[K]{
+				tenantId:   tenantId,
+				key:        item.Key,
+				expiration: exp,
+				index:      len(tenantSpecificOrderedStore.expiryListHeap),
+			}
+			tenantSpecificOrderedStore.expiryListHeap = append(tenantSpecificOrderedStore.expiryListHeap, e.ttlItem)
+		}
+		dirty = true
+		t.metrics.enqueued.Add(1)
+		if earliest.IsZero() || exp.Before(earliest) {
+			earliest = exp
+		}
+	}
+
+	if dirty {
+		heap.Init(&tenantSpecificOrderedStore.expiryListHeap)
+	}
+	if !earliest.IsZero() && tenantSpecificOrderedStore.expiryListHeap[0].expiration.Equal(earliest) {
+		t.expiryScheduled(tenantSpecificOrderedStore, earliest)
+	}
+	return results
+}
+
+// DequeueN removes and returns up to n items from the front of the tenant queue under a single lock.
+// Expired items met on the way are dropped as by Dequeue.
+func (t *tenantTTLStore[K, V]) DequeueN(tenantId string, n int) (items []Item[K, V]) {
+	if tracer := t.cfg.tracer; tracer != nil {
+		_, end := tracer.Start(context.Background(), SpanDequeueBatch, slog.String(AttrTenant, tenantId), slog.Int(AttrCount, n))
+		defer func() { end(processedAttrs(len(items))...) }()
+	}
+
+	tenantSpecificOrderedStore, ok := t.GetTenantOrderedMap(tenantId)
+	if !ok || n <= 0 {
+		return nil
+	}
+
+	tenantSpecificOrderedStore.mu.Lock()
+	defer t.unlock(tenantSpecificOrderedStore)
+
+	items = make([]Item[K, V], 0, min(n, tenantSpecificOrderedStore.order.Len()))
+	for len(items) < n && tenantSpecificOrderedStore.order.Len() > 0 {
+		if e, ok := t.dequeueLocked(tenantSpecificOrderedStore); ok {
+			items = append(items, Item[K, V]{Key: e.id, Value: e.value, ExpiresAt: e.expiryTime})
+		}
+	}
+	return items
+}
+
+// RemoveBatch deletes several keys of one tenant under a single lock.
+// The result reports for each key whether it was found.
+func (t *tenantTTLStore[K, V]) RemoveBatch(tenantId string, keys []K) []bool {
+	found := make([]bool, len(keys))
+	removed := 0
+	if tracer := t.cfg.tracer; tracer != nil {
+		_, end := tracer.Start(context.Background(), SpanRemoveBatch, slog.String(AttrTenant, tenantId), slog.Int(AttrCount, len(keys)))
+		defer func() { end(processedAttrs(removed)...) }()
+	}
+
+	tenantSpecificOrderedStore, ok := t.GetTenantOrderedMap(tenantId)
+	if !ok {
+		return found
+	}
+
+	tenantSpecificOrderedStore.mu.Lock()
+	defer t.unlock(tenantSpecificOrderedStore)
+
+	for i, key := range keys {
+		if _, ok := tenantSpecificOrderedStore.entryMap[key]; ok {
+			t.removeInternal(tenantSpecificOrderedStore, key, Removed)
+			found[i] = true
+			removed++
+		}
+	}
+	return found
+}
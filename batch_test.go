@@ -0,0 +1,122 @@
+package smartqueue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/smartqueue/smartqueuetest"
+)
+
+func TestTenantTTLStoreEnqueueBatch(t *testing.T) {
+	items := []BatchItem[int64, any]{
+		{Key: 1, Value: "one", TTL: time.Second},
+		{Key: 2, Value: "two", TTL: 500 * time.Millisecond},
+		{Key: 1, Value: "one-updated", TTL: 2 * time.Second},
+		{Key: 3, Value: "three", TTL: time.Second},
+	}
+
+	tests := []struct {
+		name        string
+		policy      EvictionPolicy
+		wantResults []EnqueueResult
+		wantKeys    []int64
+	}{
+		{
+			name:   "EvictOldest makes room",
+			policy: EvictOldest,
+			wantResults: []EnqueueResult{
+				{}, {}, {}, {CapacityReached: true},
+			},
+			wantKeys: []int64{2, 3},
+		},
+		{
+			name:   "RejectNew reports the rejected item",
+			policy: RejectNew,
+			wantResults: []EnqueueResult{
+				{}, {}, {}, {CapacityReached: true, Err: ErrCapacityReached},
+			},
+			wantKeys: []int64{1, 2},
+		},
+		{
+			name:   "EvictSoonestExpiry sees the items of the same batch",
+			policy: EvictSoonestExpiry,
+			wantResults: []EnqueueResult{
+				{}, {}, {}, {CapacityReached: true},
+			},
+			wantKeys: []int64{1, 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewTenantStore[int64, any](WithCapacity(2), WithEvictionPolicy(tt.policy))
+			defer store.Stop()
+
+			results := store.EnqueueBatch("t0001", items)
+			for i := range results {
+				if results[i] != tt.wantResults[i] {
+					t.Errorf("%s: item %d: expected %+v, got %+v", tt.name, i, tt.wantResults[i], results[i])
+				}
+			}
+
+			got := store.DequeueN("t0001", 10)
+			if len(got) != len(tt.wantKeys) {
+				t.Fatalf("%s: expected keys %v, got %+v", tt.name, tt.wantKeys, got)
+			}
+			for i := range got {
+				if got[i].Key != tt.wantKeys[i] {
+					t.Errorf("%s: expected keys %v, got %+v", tt.name, tt.wantKeys, got)
+				}
+			}
+		})
+	}
+}
+
+func TestTenantTTLStoreDequeueN(t *testing.T) {
+	clock := smartqueuetest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	store := NewTenantStore[int64, any](WithClock(clock))
+	defer store.Stop()
+
+	store.Enqueue("t0001", 1, "one", nil, time.Second)
+	store.Enqueue("t0001", 2, "expired", nil, time.Nanosecond)
+	store.Enqueue("t0001", 3, "three", nil, time.Second)
+	store.Enqueue("t0001", 4, "four", nil, time.Second)
+	clock.Advance(time.Millisecond)
+
+	got := store.DequeueN("t0001", 2)
+	if len(got) != 2 || got[0].Key != 1 || got[1].Key != 3 || got[1].Value != "three" {
+		t.Errorf("expected keys 1 and 3, got %+v", got)
+	}
+	if got := store.DequeueN("t0001", 10); len(got) != 1 || got[0].Key != 4 {
+		t.Errorf("expected the remaining key 4, got %+v", got)
+	}
+	if got := store.DequeueN("unknown", 10); got != nil {
+		t.Errorf("expected nil for an unknown tenant, got %+v", got)
+	}
+}
+
+func TestTenantTTLStoreRemoveBatch(t *testing.T) {
+	var removed []int64
+	callback := func(event Event[int64, any]) {
+		if event.Reason == Removed {
+			removed = append(removed, event.Key)
+		}
+	}
+
+	store := NewTenantStore[int64, any]()
+	defer store.Stop()
+
+	store.Enqueue("t0001", 1, "one", callback, time.Second)
+	store.Enqueue("t0001", 2, "two", callback, time.Second)
+
+	found := store.RemoveBatch("t0001", []int64{2, 5, 1})
+	if !found[0] || found[1] || !found[2] {
+		t.Errorf("expected [true false true], got %v", found)
+	}
+	if len(removed) != 2 {
+		t.Errorf("expected 2 removed callbacks, got %v", removed)
+	}
+	if _, _, ok := store.Dequeue("t0001"); ok {
+		t.Errorf("expected the tenant to be empty")
+	}
+}
@@ -0,0 +1,81 @@
+package smartqueue
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+)
+
+// DequeueWait removes and returns the front item of the tenant, waiting until one is
+// available or ctx is done. It returns ErrClosed when the store stops while waiting.
+func (t *tenantTTLStore[K, V]) DequeueWait(ctx context.Context, tenantId string) (key K, value V, err error) {
+	_, key, value, err = t.DequeueAny(ctx, tenantId)
+	return key, value, err
+}
+
+// DequeueAny removes and returns the front item of the first of tenantIds that has one,
+// waiting until any of them receives an item or ctx is done. Tenants are checked in the given order.
+func (t *tenantTTLStore[K, V]) DequeueAny(ctx context.Context, tenantIds ...string) (tenantId string, key K, value V, err error) {
+	if tracer := t.cfg.tracer; tracer != nil {
+		var end func(attrs ...slog.Attr)
+		ctx, end = tracer.Start(ctx, SpanDequeue)
+		defer func() { end(dequeuedAttrs(tenantId, key, err)...) }()
+	}
+
+	cases := make([]reflect.SelectCase, 0, len(tenantIds)+2)
+	waiting := make([]*orderedStore[K, V], 0, len(tenantIds))
+
+	for {
+		cases = append(cases[:0],
+			reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+			reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(t.stopCh)},
+		)
+		waiting = waiting[:0]
+		retry := false
+
+		for _, id := range tenantIds {
+			tenantSpecificOrderedStore := t.lockTenantStore(id)
+			if tenantSpecificOrderedStore == nil {
+				t.stopWaiting(waiting)
+				return tenantId, key, value, ErrClosed
+			}
+			if e, ok := t.dequeueLocked(tenantSpecificOrderedStore); ok {
+				t.unlock(tenantSpecificOrderedStore)
+				t.stopWaiting(waiting)
+				return id, e.id, e.value, nil
+			}
+			// the front had expired, the items behind it may not have
+			if tenantSpecificOrderedStore.order.Len() > 0 {
+				retry = true
+			}
+			tenantSpecificOrderedStore.waiters++
+			cases = append(cases, reflect.SelectCase{
+				Dir:  reflect.SelectRecv,
+				Chan: reflect.ValueOf(tenantSpecificOrderedStore.itemAdded.wait()),
+			})
+			waiting = append(waiting, tenantSpecificOrderedStore)
+			t.unlock(tenantSpecificOrderedStore)
+		}
+
+		if !retry {
+			chosen, _, _ := reflect.Select(cases)
+			switch chosen {
+			case 0:
+				t.stopWaiting(waiting)
+				return tenantId, key, value, ctx.Err()
+			case 1:
+				t.stopWaiting(waiting)
+				return tenantId, key, value, ErrClosed
+			}
+		}
+		t.stopWaiting(waiting)
+	}
+}
+
+func (t *tenantTTLStore[K, V]) stopWaiting(waiting []*orderedStore[K, V]) {
+	for _, tenantSpecificOrderedStore := range waiting {
+		tenantSpecificOrderedStore.mu.Lock()
+		tenantSpecificOrderedStore.waiters--
+		t.unlock(tenantSpecificOrderedStore)
+	}
+}
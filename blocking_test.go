@@ -0,0 +1,111 @@
+package smartqueue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTenantTTLStoreDequeueWait(t *testing.T) {
+	tests := []struct {
+		name     string
+		timeout  time.Duration
+		setup    func(store Queue[int64, any])
+		wantKey  int64
+		wantErr  error
+		stopping bool
+	}{
+		{
+			name:    "Item already queued",
+			timeout: time.Second,
+			setup: func(store Queue[int64, any]) {
+				store.Enqueue("t0001", 1, "one", nil, time.Second)
+			},
+			wantKey: 1,
+		},
+		{
+			name:    "Woken by a later enqueue",
+			timeout: time.Second,
+			setup: func(store Queue[int64, any]) {
+				go func() {
+					time.Sleep(50 * time.Millisecond)
+					store.Enqueue("t0001", 2, "two", nil, time.Second)
+				}()
+			},
+			wantKey: 2,
+		},
+		{
+			name:    "Skips an expired front",
+			timeout: time.Second,
+			setup: func(store Queue[int64, any]) {
+				store.Enqueue("t0001", 1, "expired", nil, time.Nanosecond)
+				store.Enqueue("t0001", 3, "three", nil, time.Second)
+				time.Sleep(time.Millisecond)
+			},
+			wantKey: 3,
+		},
+		{
+			name:    "Context deadline",
+			timeout: 50 * time.Millisecond,
+			setup:   func(store Queue[int64, any]) {},
+			wantErr: context.DeadlineExceeded,
+		},
+		{
+			name:    "Store stopped",
+			timeout: time.Second,
+			setup: func(store Queue[int64, any]) {
+				_ = store.CreateTenant("t0001")
+				go func() {
+					time.Sleep(50 * time.Millisecond)
+					store.Stop()
+				}()
+			},
+			wantErr:  ErrClosed,
+			stopping: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewTenantStore[int64, any]()
+			if !tt.stopping {
+				defer store.Stop()
+			}
+			tt.setup(store)
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+
+			key, _, err := store.DequeueWait(ctx, "t0001")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("%s: expected err=%v, got %v", tt.name, tt.wantErr, err)
+			}
+			if key != tt.wantKey {
+				t.Errorf("%s: expected key=%d, got %d", tt.name, tt.wantKey, key)
+			}
+		})
+	}
+}
+
+func TestTenantTTLStoreDequeueAny(t *testing.T) {
+	store := NewTenantStore[int64, any](WithIdleTenantTimeout(20 * time.Millisecond))
+	defer store.Stop()
+
+	go func() {
+		// long enough for the reaper to run while the consumer waits on empty tenants
+		time.Sleep(100 * time.Millisecond)
+		store.Enqueue("t0002", 7, "seven", nil, time.Second)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	tenantId, key, value, err := store.DequeueAny(ctx, "t0001", "t0002", "t0003")
+	if err != nil {
+		t.Fatalf("expected an item, got %v", err)
+	}
+	if tenantId != "t0002" || key != 7 || value != "seven" {
+		t.Errorf("expected t0002/7/seven, got %s/%d/%v", tenantId, key, value)
+	}
+}
@@ -0,0 +1,110 @@
+package smartqueue
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Reason tells a callback why an item left the queue.
+type Reason int
+
+const (
+	// Expired means the item reached its TTL.
+	Expired Reason = iota + 1
+	// Evicted means the item was removed to make room in a full tenant.
+	Evicted
+	// Replaced means the item was overwritten by an Enqueue of the same key.
+	Replaced
+	// Removed means the item was deleted explicitly.
+	Removed
+	// Shutdown means the item was still queued when the store shut down.
+	Shutdown
+	// DeadLettered means the item went over the delivery limit and moved to the dead-letter store.
+	DeadLettered
+)
+
+func (r Reason) String() string {
+	switch r {
+	case Expired:
+		return "expired"
+	case Evicted:
+		return "evicted"
+	case Replaced:
+		return "replaced"
+	case Removed:
+		return "removed"
+	case Shutdown:
+		return "shutdown"
+	case DeadLettered:
+		return "dead_lettered"
+	default:
+		return "unknown"
+	}
+}
+
+// Event describes an item leaving the queue.
+type Event[K comparable, V any] struct {
+	TenantID   string
+	Key        K
+	Value      V
+	Reason     Reason
+	EnqueuedAt time.Time
+	ExpiresAt  time.Time
+	// OccurredAt is when the item left the queue.
+	OccurredAt time.Time
+}
+
+// Callback receives the events of the item it was enqueued with.
+type Callback[K comparable, V any] func(event Event[K, V])
+
+// ExpiryCallback adapts a callback with the original func(tenantId, key) signature.
+// Like before, it fires when an item expires or is evicted for capacity, and ignores other reasons.
+func ExpiryCallback[K comparable, V any](callback func(tenantId string, key K)) Callback[K, V] {
+	if callback == nil {
+		return nil
+	}
+	return func(event Event[K, V]) {
+		if event.Reason == Expired || event.Reason == Evicted {
+			callback(event.TenantID, event.Key)
+		}
+	}
+}
+
+// fire queues the event for e; it is dispatched once the tenant lock is released.
+// Caller must hold tenantStore.mu
+func (t *tenantTTLStore[K, V]) fire(tenantStore *orderedStore[K, V], e *entry[K, V], reason Reason) {
+	if e.callback == nil {
+		return
+	}
+	callback := e.callback
+	event := Event[K, V]{
+		TenantID:   tenantStore.tenantId,
+		Key:        e.id,
+		Value:      e.value,
+		Reason:     reason,
+		EnqueuedAt: e.enqueuedAt,
+		ExpiresAt:  e.expiryTime,
+		OccurredAt: t.cfg.clock.Now(),
+	}
+	run := func() {
+		callback(event)
+	}
+	if tracer := t.cfg.tracer; tracer != nil {
+		run = func() {
+			_, end := tracer.Start(context.Background(), SpanCallback, slog.String(AttrTenant, event.TenantID),
+				slog.Any(AttrKey, event.Key), slog.String(AttrReason, reason.String()))
+			panicked := true
+			defer func() {
+				if panicked {
+					end(slog.String(AttrOutcome, "panic"))
+				} else {
+					end(errorOutcome(nil))
+				}
+			}()
+			callback(event)
+			panicked = false
+		}
+	}
+	tenantStore.pending = append(tenantStore.pending, run)
+}
@@ -0,0 +1,140 @@
+package smartqueue
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/smartqueue/smartqueuetest"
+)
+
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []Event[int64, any]
+}
+
+func (r *eventRecorder) callback(event Event[int64, any]) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *eventRecorder) reasons() []Reason {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var reasons []Reason
+	for _, event := range r.events {
+		reasons = append(reasons, event.Reason)
+	}
+	return reasons
+}
+
+// wait polls until n events were recorded, since expiry callbacks fire from the cleanup loop.
+func (r *eventRecorder) wait(n int) {
+	for deadline := time.Now().Add(time.Second); len(r.reasons()) < n && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestTenantTTLStoreCallbackReasons(t *testing.T) {
+	tests := []struct {
+		name        string
+		act         func(store *tenantTTLStore[int64, any], clock *smartqueuetest.FakeClock, callback Callback[int64, any])
+		wantReasons []Reason
+		wantValue   any
+	}{
+		{
+			name: "TTL expiry",
+			act: func(store *tenantTTLStore[int64, any], clock *smartqueuetest.FakeClock, callback Callback[int64, any]) {
+				store.Enqueue("t0001", 1, "one", callback, 50*time.Millisecond)
+				clock.Advance(150 * time.Millisecond)
+			},
+			wantReasons: []Reason{Expired},
+			wantValue:   "one",
+		},
+		{
+			name: "Capacity eviction",
+			act: func(store *tenantTTLStore[int64, any], clock *smartqueuetest.FakeClock, callback Callback[int64, any]) {
+				store.Enqueue("t0001", 1, "one", callback, 5*time.Second)
+				store.Enqueue("t0001", 2, "two", nil, 5*time.Second)
+				store.Enqueue("t0001", 3, "three", nil, 5*time.Second)
+			},
+			wantReasons: []Reason{Evicted},
+			wantValue:   "one",
+		},
+		{
+			name: "Replaced by enqueue of the same key",
+			act: func(store *tenantTTLStore[int64, any], clock *smartqueuetest.FakeClock, callback Callback[int64, any]) {
+				store.Enqueue("t0001", 1, "one", callback, 5*time.Second)
+				store.Enqueue("t0001", 1, "updated", nil, 5*time.Second)
+			},
+			wantReasons: []Reason{Replaced},
+			wantValue:   "one",
+		},
+		{
+			name: "Explicit remove",
+			act: func(store *tenantTTLStore[int64, any], clock *smartqueuetest.FakeClock, callback Callback[int64, any]) {
+				store.Enqueue("t0001", 1, "one", callback, 5*time.Second)
+				store.Remove("t0001", 1)
+			},
+			wantReasons: []Reason{Removed},
+			wantValue:   "one",
+		},
+		{
+			name: "Dequeue fires nothing",
+			act: func(store *tenantTTLStore[int64, any], clock *smartqueuetest.FakeClock, callback Callback[int64, any]) {
+				store.Enqueue("t0001", 1, "one", callback, 5*time.Second)
+				store.Dequeue("t0001")
+			},
+			wantReasons: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := smartqueuetest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+			store := NewTenantStore[int64, any](WithClock(clock), WithCapacity(2)).(*tenantTTLStore[int64, any])
+			defer store.Stop()
+
+			recorder := &eventRecorder{}
+			tt.act(store, clock, recorder.callback)
+			recorder.wait(len(tt.wantReasons))
+
+			reasons := recorder.reasons()
+			if len(reasons) != len(tt.wantReasons) {
+				t.Fatalf("%s: expected reasons %v, got %v", tt.name, tt.wantReasons, reasons)
+			}
+			for i := range reasons {
+				if reasons[i] != tt.wantReasons[i] {
+					t.Errorf("%s: expected reason %v, got %v", tt.name, tt.wantReasons[i], reasons[i])
+				}
+			}
+			if len(reasons) > 0 {
+				event := recorder.events[0]
+				if event.Value != tt.wantValue || event.TenantID != "t0001" || event.Key != 1 {
+					t.Errorf("%s: unexpected event %+v", tt.name, event)
+				}
+				if event.EnqueuedAt.IsZero() || event.ExpiresAt.IsZero() || event.OccurredAt.IsZero() {
+					t.Errorf("%s: expected event timestamps, got %+v", tt.name, event)
+				}
+			}
+		})
+	}
+}
+
+func TestExpiryCallback(t *testing.T) {
+	var fired []Reason
+	for _, reason := range []Reason{Expired, Evicted, Replaced, Removed, Shutdown} {
+		wrapped := ExpiryCallback[int64, any](func(tenantId string, key int64) {
+			fired = append(fired, reason)
+		})
+		wrapped(Event[int64, any]{TenantID: "t0001", Key: 1, Reason: reason})
+	}
+
+	if len(fired) != 2 || fired[0] != Expired || fired[1] != Evicted {
+		t.Errorf("expected only Expired and Evicted to fire, got %v", fired)
+	}
+	if ExpiryCallback[int64, any](nil) != nil {
+		t.Errorf("expected nil callback to stay nil")
+	}
+}
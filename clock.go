@@ -0,0 +1,23 @@
+package smartqueue
+
+import "time"
+
+// Clock is the source of time for expiry, leases, delays and idle tenant reaping.
+// It only uses standard library types, so fakes such as smartqueuetest.FakeClock need not import this package.
+type Clock interface {
+	Now() time.Time
+	// NewTimer returns a channel that receives the time once d has passed and a function that
+	// stops the timer, reporting whether it was still pending.
+	NewTimer(d time.Duration) (c <-chan time.Time, stop func() bool)
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTimer(d time.Duration) (<-chan time.Time, func() bool) {
+	timer := time.NewTimer(d)
+	return timer.C, timer.Stop
+}
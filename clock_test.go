@@ -0,0 +1,66 @@
+package smartqueue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/smartqueue/smartqueuetest"
+)
+
+var _ Clock = (*smartqueuetest.FakeClock)(nil)
+
+func TestTenantTTLStoreFakeClock(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{name: "Per-tenant cleanup loop", opts: nil},
+		{name: "Shared scheduler", opts: []Option{WithSharedScheduler(1)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := smartqueuetest.NewFakeClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+			store := NewTenantStore[int64, any](append(tt.opts, WithClock(clock))...)
+			defer store.Stop()
+
+			expired := make(chan int64, 10)
+			callback := func(event Event[int64, any]) {
+				if event.Reason == Expired {
+					expired <- event.Key
+				}
+			}
+			store.Enqueue("t0001", 1, "hour", callback, time.Hour)
+			store.Enqueue("t0001", 2, "minute", callback, time.Minute)
+			store.EnqueueAfter("t0001", 3, "delayed", 10*time.Minute, callback, time.Minute)
+
+			steps := []struct {
+				advance     time.Duration
+				wantExpired []int64
+				wantLen     int
+			}{
+				{advance: 59 * time.Second, wantLen: 3},
+				{advance: time.Second, wantExpired: []int64{2}, wantLen: 2},
+				{advance: 9 * time.Minute, wantLen: 2},
+				{advance: time.Minute, wantExpired: []int64{3}, wantLen: 1},
+				{advance: time.Hour, wantExpired: []int64{1}, wantLen: 0},
+			}
+			for i, step := range steps {
+				clock.Advance(step.advance)
+				for _, want := range step.wantExpired {
+					select {
+					case got := <-expired:
+						if got != want {
+							t.Errorf("%s: step %d: expected key %d to expire, got %d", tt.name, i, want, got)
+						}
+					case <-time.After(time.Second):
+						t.Fatalf("%s: step %d: expected key %d to expire", tt.name, i, want)
+					}
+				}
+				if got := store.Len("t0001"); got != step.wantLen {
+					t.Errorf("%s: step %d: expected %d items, got %d", tt.name, i, step.wantLen, got)
+				}
+			}
+		})
+	}
+}
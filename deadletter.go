@@ -0,0 +1,133 @@
+package smartqueue
+
+import "time"
+
+// DeadLetter is an item that went over the delivery limit of its tenant.
+type DeadLetter[K comparable, V any] struct {
+	Key            K
+	Value          V
+	Deliveries     int
+	DeadLetteredAt time.Time
+}
+
+// DeadLetters returns the dead letters of the tenant, oldest first.
+func (t *tenantTTLStore[K, V]) DeadLetters(tenantId string) []DeadLetter[K, V] {
+	tenantSpecificOrderedStore, ok := t.GetTenantOrderedMap(tenantId)
+	if !ok {
+		return nil
+	}
+
+	tenantSpecificOrderedStore.mu.RLock()
+	defer tenantSpecificOrderedStore.mu.RUnlock()
+
+	deadLetters := tenantSpecificOrderedStore.deadLetters
+	if deadLetters == nil {
+		return nil
+	}
+	items := make([]DeadLetter[K, V], 0, deadLetters.order.Len())
+	for elem := range deadLetters.order.All() {
+		e := deadLetters.entryMap[elem.Value.(K)]
+		items = append(items, DeadLetter[K, V]{
+			Key:            e.id,
+			Value:          e.value,
+			Deliveries:     e.deliveries,
+			DeadLetteredAt: e.lastAccess,
+		})
+	}
+	return items
+}
+
+// Redrive moves dead letters back to the end of the tenant queue with their original TTL and
+// a fresh delivery count. With no keys every dead letter is redriven. It stops when the tenant is full
+// and returns the number of items moved.
+func (t *tenantTTLStore[K, V]) Redrive(tenantId string, keys ...K) int {
+	tenantSpecificOrderedStore, ok := t.GetTenantOrderedMap(tenantId)
+	if !ok {
+		return 0
+	}
+
+	tenantSpecificOrderedStore.mu.Lock()
+	defer t.unlock(tenantSpecificOrderedStore)
+
+	deadLetters := tenantSpecificOrderedStore.deadLetters
+	if deadLetters == nil {
+		return 0
+	}
+	if len(keys) == 0 {
+		for elem := range deadLetters.order.All() {
+			keys = append(keys, elem.Value.(K))
+		}
+	}
+
+	now := t.cfg.clock.Now()
+	redriven := 0
+	for _, key := range keys {
+		e, ok := deadLetters.entryMap[key]
+		if !ok {
+			continue
+		}
+		if _, exists := tenantSpecificOrderedStore.entryMap[key]; !exists &&
+			tenantSpecificOrderedStore.size.Load() >= tenantSpecificOrderedStore.capacity {
+			break
+		}
+
+		deadLetters.order.Remove(e.element, DefaultPriority)
+		delete(deadLetters.entryMap, key)
+		deadLetters.size.Add(-1)
+
+		exp := t.putLocked(tenantSpecificOrderedStore, key, e.value, e.priority, time.Time{}, e.callback, e.ttl, now)
+		t.setExpiry(tenantSpecificOrderedStore, tenantSpecificOrderedStore.entryMap[key], exp)
+		redriven++
+	}
+	if len(deadLetters.entryMap) == 0 {
+		tenantSpecificOrderedStore.deadLetters = nil
+	}
+	return redriven
+}
+
+// deadLetter moves a leased item that went over the delivery limit into the dead-letter store of its tenant.
+// The dead-letter store is guarded by the tenant lock and holds at most the tenant capacity, dropping its oldest item.
+// Caller must hold tenantSpecificOrderedStore.mu
+func (t *tenantTTLStore[K, V]) deadLetter(tenantSpecificOrderedStore *orderedStore[K, V], e *entry[K, V]) {
+	t.fire(tenantSpecificOrderedStore, e, DeadLettered)
+	delete(tenantSpecificOrderedStore.leases, e.leaseToken)
+	delete(tenantSpecificOrderedStore.entryMap, e.id)
+	t.stopTimers(tenantSpecificOrderedStore, e)
+	tenantSpecificOrderedStore.access.remove(e)
+	tenantSpecificOrderedStore.size.Add(-1)
+	tenantSpecificOrderedStore.spaceFreed.notify()
+	e.leaseToken = ""
+	e.leaseDeadline = time.Time{}
+	e.lastAccess = t.cfg.clock.Now()
+
+	t.pushDeadLetter(tenantSpecificOrderedStore, e)
+	t.cfg.logger.Debug("smartqueue: dead-lettered item", "tenant", tenantSpecificOrderedStore.tenantId, "key", e.id, "deliveries", e.deliveries)
+}
+
+// pushDeadLetter appends e, whose lastAccess holds the time it was dead-lettered, to the dead-letter store of its tenant.
+// Dead letters all share one level, so they stay in the order they were dead-lettered whatever their priority.
+// Caller must hold tenantSpecificOrderedStore.mu
+func (t *tenantTTLStore[K, V]) pushDeadLetter(tenantSpecificOrderedStore *orderedStore[K, V], e *entry[K, V]) {
+	if tenantSpecificOrderedStore.deadLetters == nil {
+		tenantSpecificOrderedStore.deadLetters = newOrderedStore[K, V](tenantSpecificOrderedStore.tenantId, TenantConfig{})
+	}
+	deadLetters := tenantSpecificOrderedStore.deadLetters
+	if old, ok := deadLetters.entryMap[e.id]; ok {
+		deadLetters.order.Remove(old.element, DefaultPriority)
+		delete(deadLetters.entryMap, e.id)
+		deadLetters.size.Add(-1)
+	}
+	if deadLetters.size.Load() >= tenantSpecificOrderedStore.capacity {
+		front := deadLetters.order.Front()
+		dropped := deadLetters.entryMap[front.Value.(K)]
+		t.fire(tenantSpecificOrderedStore, dropped, Evicted)
+		deadLetters.order.Remove(front, DefaultPriority)
+		delete(deadLetters.entryMap, dropped.id)
+		deadLetters.size.Add(-1)
+		t.cfg.logger.Debug("smartqueue: dropped oldest dead letter", "tenant", tenantSpecificOrderedStore.tenantId, "key", dropped.id)
+	}
+
+	e.element, e.seq = deadLetters.order.PushBack(e.id, DefaultPriority)
+	deadLetters.entryMap[e.id] = e
+	deadLetters.size.Add(1)
+}
@@ -0,0 +1,137 @@
+package smartqueue
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/smartqueue/smartqueuetest"
+)
+
+func TestTenantTTLStoreDeadLetters(t *testing.T) {
+	tests := []struct {
+		name           string
+		maxDeliveries  int
+		nacks          int
+		wantDeadLetter bool
+		wantReasons    []Reason
+	}{
+		{
+			name:          "Under the limit stays queued",
+			maxDeliveries: 3,
+			nacks:         2,
+		},
+		{
+			name:           "Over the limit is dead-lettered",
+			maxDeliveries:  3,
+			nacks:          3,
+			wantDeadLetter: true,
+			wantReasons:    []Reason{DeadLettered},
+		},
+		{
+			name:  "No limit",
+			nacks: 5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewTenantStore[int64, any](WithMaxDeliveries(tt.maxDeliveries))
+			defer store.Stop()
+
+			recorder := &eventRecorder{}
+			store.Enqueue("t0001", 1, "one", recorder.callback, time.Minute)
+			for i := 0; i < tt.nacks; i++ {
+				lease, ok := store.Lease("t0001", time.Minute)
+				if !ok || lease.Deliveries != i+1 {
+					t.Fatalf("%s: delivery %d: unexpected lease %+v, %v", tt.name, i+1, lease, ok)
+				}
+				if err := store.Nack("t0001", lease.Token); err != nil {
+					t.Fatalf("%s: unexpected error %v", tt.name, err)
+				}
+			}
+
+			deadLetters := store.DeadLetters("t0001")
+			if tt.wantDeadLetter != (len(deadLetters) == 1) {
+				t.Fatalf("%s: unexpected dead letters %+v", tt.name, deadLetters)
+			}
+			if _, ok := store.Lease("t0001", time.Minute); ok == tt.wantDeadLetter {
+				t.Errorf("%s: expected item queued %v", tt.name, !tt.wantDeadLetter)
+			}
+			if tt.wantDeadLetter && (deadLetters[0].Key != 1 || deadLetters[0].Deliveries != tt.maxDeliveries) {
+				t.Errorf("%s: unexpected dead letter %+v", tt.name, deadLetters[0])
+			}
+			if reasons := recorder.reasons(); !slices.Equal(reasons, tt.wantReasons) {
+				t.Errorf("%s: expected reasons %v, got %v", tt.name, tt.wantReasons, reasons)
+			}
+		})
+	}
+}
+
+func TestTenantTTLStoreDeadLetterOnLeaseExpiry(t *testing.T) {
+	clock := smartqueuetest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	store := NewTenantStore[int64, any](WithClock(clock), WithMaxDeliveries(1), WithIdlePollInterval(10*time.Millisecond))
+	defer store.Stop()
+
+	store.Enqueue("t0001", 1, "one", nil, time.Minute)
+	store.Lease("t0001", 20*time.Millisecond)
+	clock.Advance(100 * time.Millisecond)
+	for deadline := time.Now().Add(time.Second); len(store.DeadLetters("t0001")) == 0 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+
+	if got := store.DeadLetters("t0001"); len(got) != 1 || got[0].Key != 1 {
+		t.Errorf("expected key 1 dead-lettered, got %+v", got)
+	}
+}
+
+func TestTenantTTLStoreRedrive(t *testing.T) {
+	store := NewTenantStore[int64, any](WithMaxDeliveries(1), WithCapacity(2))
+	defer store.Stop()
+
+	for _, key := range []int64{1, 2, 3} {
+		store.Enqueue("t0001", key, "value", nil, time.Minute)
+		lease, _ := store.Lease("t0001", time.Minute)
+		store.Nack("t0001", lease.Token)
+	}
+	if got := store.DeadLetters("t0001"); len(got) != 2 || got[0].Key != 2 || got[1].Key != 3 {
+		t.Fatalf("expected the oldest dead letter dropped, got %+v", got)
+	}
+
+	store.Enqueue("t0001", 4, "four", nil, time.Minute)
+	if got := store.Redrive("t0001"); got != 1 {
+		t.Errorf("expected 1 item redriven into the free slot, got %d", got)
+	}
+	if got := store.Redrive("t0001", 3); got != 0 {
+		t.Errorf("expected nothing redriven into a full tenant, got %d", got)
+	}
+
+	got := store.DequeueN("t0001", 10)
+	if len(got) != 2 || got[0].Key != 4 || got[1].Key != 2 {
+		t.Errorf("expected keys [4 2], got %+v", got)
+	}
+	lease, ok := store.Lease("t0001", time.Minute)
+	if ok {
+		t.Errorf("unexpected lease %+v", lease)
+	}
+	if got := store.Redrive("t0001", 3); got != 1 {
+		t.Errorf("expected key 3 redriven, got %d", got)
+	}
+	if lease, ok := store.Lease("t0001", time.Minute); !ok || lease.Key != 3 || lease.Deliveries != 1 {
+		t.Errorf("expected key 3 with a fresh delivery count, got %+v", lease)
+	}
+}
+
+func TestTenantTTLStoreDeadLettersIgnorePriority(t *testing.T) {
+	store := NewTenantStore[int64, any](WithMaxDeliveries(1), WithCapacity(2))
+	defer store.Stop()
+
+	for _, key := range []int64{1, 2, 3} {
+		store.EnqueueWithPriority("t0001", key, "value", Priority(key*5), nil, time.Minute)
+		lease, _ := store.Lease("t0001", time.Minute)
+		store.Nack("t0001", lease.Token)
+	}
+	if got := store.DeadLetters("t0001"); len(got) != 2 || got[0].Key != 2 || got[1].Key != 3 {
+		t.Errorf("expected the oldest dead letter dropped whatever its priority, got %+v", got)
+	}
+}
@@ -0,0 +1,21 @@
+package smartqueue
+
+import (
+	"context"
+	"time"
+)
+
+// EnqueueAt inserts or updates key like EnqueueContext, but the item can only be dequeued from readyAt on.
+// Its TTL starts at readyAt. A readyAt in the past makes the item ready straight away.
+func (t *tenantTTLStore[K, V]) EnqueueAt(tenantId string, key K, value V, readyAt time.Time,
+	callback Callback[K, V], ttl time.Duration) (capacityReached bool, err error) {
+
+	return t.enqueue(context.Background(), tenantId, key, value, DefaultPriority, readyAt, callback, ttl)
+}
+
+// EnqueueAfter is EnqueueAt with a ready time of delay from now.
+func (t *tenantTTLStore[K, V]) EnqueueAfter(tenantId string, key K, value V, delay time.Duration,
+	callback Callback[K, V], ttl time.Duration) (capacityReached bool, err error) {
+
+	return t.EnqueueAt(tenantId, key, value, t.cfg.clock.Now().Add(delay), callback, ttl)
+}
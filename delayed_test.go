@@ -0,0 +1,88 @@
+package smartqueue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/smartqueue/smartqueuetest"
+)
+
+func TestTenantTTLStoreEnqueueAfter(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{name: "Per-tenant cleanup loop", opts: nil},
+		{name: "Shared scheduler", opts: []Option{WithSharedScheduler(2)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := smartqueuetest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+			store := NewTenantStore[int64, any](append(tt.opts, WithClock(clock))...)
+			defer store.Stop()
+
+			store.Enqueue("t0001", 1, "long", nil, time.Hour)
+			store.EnqueueAfter("t0001", 2, "delayed", 50*time.Millisecond, nil, 100*time.Millisecond)
+
+			if _, _, ok := store.Dequeue("t0001"); !ok {
+				t.Fatalf("%s: expected the ready item", tt.name)
+			}
+			if k, _, ok := store.Dequeue("t0001"); ok {
+				t.Fatalf("%s: expected the delayed item hidden, got %d", tt.name, k)
+			}
+			if _, ok := store.Pop("t0001", 2); ok {
+				t.Fatalf("%s: expected the delayed item hidden from Pop", tt.name)
+			}
+
+			// the TTL starts once the item is ready, so it is still there after 120ms
+			clock.Advance(120 * time.Millisecond)
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			if k, v, err := store.DequeueWait(ctx, "t0001"); err != nil || k != 2 || v != "delayed" {
+				t.Errorf("%s: expected the delayed item, got %d, %v, %v", tt.name, k, v, err)
+			}
+		})
+	}
+}
+
+func TestTenantTTLStoreEnqueueAt(t *testing.T) {
+	clock := smartqueuetest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	store := NewTenantStore[int64, any](WithClock(clock))
+	defer store.Stop()
+
+	tests := []struct {
+		name    string
+		readyAt time.Time
+		wantNow bool
+	}{
+		{name: "Ready time in the past", readyAt: clock.Now().Add(-time.Second), wantNow: true},
+		{name: "Ready time in the future", readyAt: clock.Now().Add(time.Hour), wantNow: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store.EnqueueAt("t0001", 1, "value", tt.readyAt, nil, time.Minute)
+			if _, ok := store.Pop("t0001", 1); ok != tt.wantNow {
+				t.Errorf("%s: expected ready %v, got %v", tt.name, tt.wantNow, ok)
+			}
+			store.Remove("t0001", 1)
+		})
+	}
+}
+
+func TestTenantTTLStoreDelayedWakesDequeueWait(t *testing.T) {
+	clock := smartqueuetest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	store := NewTenantStore[int64, any](WithClock(clock))
+	defer store.Stop()
+
+	store.EnqueueAfter("t0001", 1, "value", 30*time.Millisecond, nil, time.Minute)
+	go clock.Advance(30 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if k, _, err := store.DequeueWait(ctx, "t0001"); err != nil || k != 1 {
+		t.Errorf("expected key 1, got %d, %v", k, err)
+	}
+}
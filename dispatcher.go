@@ -0,0 +1,166 @@
+package smartqueue
+
+import (
+	"hash/fnv"
+	"sync"
+)
+
+// Dispatcher delivers callbacks after the tenant lock has been released.
+// Dispatch reports false when the task was dropped.
+type Dispatcher interface {
+	Dispatch(tenantId string, task func()) bool
+	// Close stops accepting tasks and waits for queued ones to finish.
+	Close()
+}
+
+// BackpressurePolicy decides what a worker pool dispatcher does when its queue is full.
+type BackpressurePolicy int
+
+const (
+	// DropWhenFull discards the callback and logs a warning.
+	DropWhenFull BackpressurePolicy = iota
+	// BlockWhenFull makes the expiring goroutine wait for queue space.
+	BlockWhenFull
+)
+
+// syncDispatcher runs each callback on the goroutine that released the tenant lock.
+type syncDispatcher struct{}
+
+// NewSyncDispatcher returns a Dispatcher that runs callbacks synchronously. It is the default.
+func NewSyncDispatcher() Dispatcher {
+	return syncDispatcher{}
+}
+
+func (syncDispatcher) Dispatch(_ string, task func()) bool {
+	task()
+	return true
+}
+
+func (syncDispatcher) Close() {}
+
+// executorDispatcher adapts a WithCallbackExecutor function.
+type executorDispatcher func(task func())
+
+func (e executorDispatcher) Dispatch(_ string, task func()) bool {
+	e(task)
+	return true
+}
+
+func (executorDispatcher) Close() {}
+
+type poolDispatcher struct {
+	mu      sync.RWMutex
+	closed  bool
+	queues  []chan func()
+	policy  BackpressurePolicy
+	ordered bool
+	wg      sync.WaitGroup
+}
+
+// NewPoolDispatcher returns a Dispatcher that runs callbacks on a bounded pool of workers
+// sharing one queue of queueSize tasks. Callbacks of one tenant may run concurrently.
+func NewPoolDispatcher(workers, queueSize int, policy BackpressurePolicy) Dispatcher {
+	workers = max(workers, 1)
+	d := &poolDispatcher{
+		queues: []chan func(){make(chan func(), max(queueSize, 0))},
+		policy: policy,
+	}
+	for i := 0; i < workers; i++ {
+		d.start(d.queues[0])
+	}
+	return d
+}
+
+// NewOrderedDispatcher returns a Dispatcher that runs the callbacks of each tenant in order.
+// Tenants are spread over workers, each with its own queue of queueSize tasks.
+func NewOrderedDispatcher(workers, queueSize int, policy BackpressurePolicy) Dispatcher {
+	workers = max(workers, 1)
+	d := &poolDispatcher{
+		queues:  make([]chan func(), workers),
+		policy:  policy,
+		ordered: true,
+	}
+	for i := range d.queues {
+		d.queues[i] = make(chan func(), max(queueSize, 0))
+		d.start(d.queues[i])
+	}
+	return d
+}
+
+func (d *poolDispatcher) start(queue chan func()) {
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		for task := range queue {
+			task()
+		}
+	}()
+}
+
+func (d *poolDispatcher) Dispatch(tenantId string, task func()) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closed {
+		return false
+	}
+
+	queue := d.queues[0]
+	if d.ordered {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(tenantId))
+		queue = d.queues[h.Sum32()%uint32(len(d.queues))]
+	}
+
+	if d.policy == BlockWhenFull {
+		queue <- task
+		return true
+	}
+	select {
+	case queue <- task:
+		return true
+	default:
+		return false
+	}
+}
+
+func (d *poolDispatcher) Close() {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		for _, queue := range d.queues {
+			close(queue)
+		}
+	}
+	d.mu.Unlock()
+	d.wg.Wait()
+}
+
+// dispatch hands a callback to the dispatcher, recovering and logging any panic it raises.
+func (t *tenantTTLStore[K, V]) dispatch(tenantId string, task func()) {
+	safe := func() {
+		start := t.cfg.clock.Now()
+		defer func() {
+			t.metrics.callbackDuration.observe(t.cfg.clock.Now().Sub(start))
+			if r := recover(); r != nil {
+				t.metrics.callbackPanics.Add(1)
+				t.cfg.logger.Error("smartqueue: callback panicked", "tenant", tenantId, "panic", r)
+			}
+		}()
+		task()
+	}
+	if !t.cfg.dispatcher.Dispatch(tenantId, safe) {
+		t.metrics.callbacksDropped.Add(1)
+		t.cfg.logger.Warn("smartqueue: callback dropped", "tenant", tenantId)
+	}
+}
+
+// unlock releases tenantStore.mu and dispatches the callbacks queued while it was held.
+func (t *tenantTTLStore[K, V]) unlock(tenantStore *orderedStore[K, V]) {
+	pending := tenantStore.pending
+	tenantStore.pending = nil
+	tenantStore.mu.Unlock()
+
+	for _, task := range pending {
+		t.dispatch(tenantStore.tenantId, task)
+	}
+}
@@ -0,0 +1,110 @@
+package smartqueue
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDispatchers(t *testing.T) {
+	tests := []struct {
+		name       string
+		dispatcher func() Dispatcher
+	}{
+		{name: "Sync", dispatcher: NewSyncDispatcher},
+		{name: "Pool", dispatcher: func() Dispatcher { return NewPoolDispatcher(4, 16, BlockWhenFull) }},
+		{name: "Ordered", dispatcher: func() Dispatcher { return NewOrderedDispatcher(4, 16, BlockWhenFull) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var delivered atomic.Int32
+			var reentered atomic.Bool
+
+			store := NewTenantStore[int64, any](WithCapacity(1), WithDispatcher(tt.dispatcher()))
+			// the callback calls back into the store, which deadlocked while callbacks ran under the tenant lock
+			callback := func(event Event[int64, any]) {
+				if _, ok := store.Pop(event.TenantID, 2); ok {
+					reentered.Store(true)
+				}
+				delivered.Add(1)
+			}
+
+			store.Enqueue("t0001", 1, "one", callback, 5*time.Second)
+			store.Enqueue("t0001", 2, "two", callback, 5*time.Second)
+			// Stop drops the remaining items, so let the callback read them first
+			for deadline := time.Now().Add(time.Second); delivered.Load() == 0 && time.Now().Before(deadline); {
+				time.Sleep(time.Millisecond)
+			}
+			store.Stop()
+
+			if delivered.Load() != 1 {
+				t.Errorf("%s: expected 1 delivered callback, got %d", tt.name, delivered.Load())
+			}
+			if !reentered.Load() {
+				t.Errorf("%s: expected the callback to read from the store", tt.name)
+			}
+		})
+	}
+}
+
+func TestOrderedDispatcherKeepsTenantOrder(t *testing.T) {
+	dispatcher := NewOrderedDispatcher(4, 1024, BlockWhenFull)
+
+	var mu sync.Mutex
+	got := make(map[string][]int)
+	for i := 0; i < 100; i++ {
+		for _, tenantId := range []string{"t0001", "t0002", "t0003"} {
+			tenantId, i := tenantId, i
+			dispatcher.Dispatch(tenantId, func() {
+				mu.Lock()
+				got[tenantId] = append(got[tenantId], i)
+				mu.Unlock()
+			})
+		}
+	}
+	dispatcher.Close()
+
+	for tenantId, seq := range got {
+		for i := range seq {
+			if seq[i] != i {
+				t.Fatalf("tenant %s: callbacks out of order: %v", tenantId, seq)
+			}
+		}
+	}
+}
+
+func TestPoolDispatcherDropWhenFull(t *testing.T) {
+	dispatcher := NewPoolDispatcher(1, 1, DropWhenFull)
+	release := make(chan struct{})
+
+	dispatcher.Dispatch("t0001", func() { <-release })
+	// fill the queue once the worker has picked up the blocking task
+	for i := 0; i < 100 && !dispatcher.Dispatch("t0001", func() {}); i++ {
+		time.Sleep(time.Millisecond)
+	}
+	if dispatcher.Dispatch("t0001", func() {}) {
+		t.Errorf("expected the dispatch to be dropped while the queue is full")
+	}
+
+	close(release)
+	dispatcher.Close()
+	if dispatcher.Dispatch("t0001", func() {}) {
+		t.Errorf("expected the dispatch to be dropped after Close")
+	}
+}
+
+func TestDispatchRecoversPanics(t *testing.T) {
+	var delivered atomic.Int32
+	store := NewTenantStore[int64, any](WithCapacity(1))
+	defer store.Stop()
+
+	store.Enqueue("t0001", 1, "one", func(event Event[int64, any]) { panic("boom") }, 5*time.Second)
+	store.Enqueue("t0001", 2, "two", func(event Event[int64, any]) { delivered.Add(1) }, 5*time.Second)
+	store.Remove("t0001", 2)
+
+	if delivered.Load() != 1 {
+		t.Errorf("expected callbacks to keep running after a panic, got %d", delivered.Load())
+	}
+}
@@ -6,10 +6,60 @@ import (
 )
 
 // Item element that will insert to the queue
-type entry struct {
-	id         int64
-	value      interface{}
+type entry[K comparable, V any] struct {
+	id         K
+	value      V
 	expiryTime time.Time
 	element    *list.Element
-	expiryFunc func(tenantId string, key int64)
+	// seq is the sequence number of element in its priority level
+	seq        int64
+	enqueuedAt time.Time
+	callback   Callback[K, V]
+	lastAccess time.Time
+	hits       int64
+	// positions in the access order of the tenant
+	recentElem *list.Element
+	bucket     *list.Element
+	hitElem    *list.Element
+	// set while the item is leased and out of the order list
+	leaseToken    string
+	leaseDeadline time.Time
+	deliveries    int
+	priority      Priority
+	// set while the item waits for its ready time, out of the order list
+	readyAt time.Time
+	// the ttl the item was enqueued with, restarted by Touch
+	ttl time.Duration
+	// heap items of expiryTime, leaseDeadline and readyAt; nil when not set
+	ttlItem   *expiry[K]
+	leaseItem *expiry[K]
+	readyItem *expiry[K]
+}
+
+func (e *entry[K, V]) leased() bool {
+	return e.leaseToken != ""
+}
+
+func (e *entry[K, V]) delayed() bool {
+	return !e.readyAt.IsZero()
+}
+
+// ttlStart returns when a TTL restarted at now starts counting: a delayed item's TTL starts once it is ready.
+func (e *entry[K, V]) ttlStart(now time.Time) time.Time {
+	if e.readyAt.After(now) {
+		return e.readyAt
+	}
+	return now
+}
+
+// expired reports whether the TTL of e has passed. Persisted items have a zero expiryTime and never expire.
+func (e *entry[K, V]) expired(now time.Time) bool {
+	return !e.expiryTime.IsZero() && now.After(e.expiryTime)
+}
+
+func (e *entry[K, V]) remaining(now time.Time) time.Duration {
+	if e.expiryTime.IsZero() {
+		return NoExpiry
+	}
+	return e.expiryTime.Sub(now)
 }
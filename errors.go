@@ -0,0 +1,20 @@
+package smartqueue
+
+import "errors"
+
+var (
+	// ErrInvalidTenantConfig is returned when a TenantConfig holds negative limits.
+	ErrInvalidTenantConfig = errors.New("smartqueue: invalid tenant config")
+	// ErrCapacityReached is returned when a full tenant rejects a new item.
+	ErrCapacityReached = errors.New("smartqueue: tenant capacity reached")
+	// ErrTenantExists is returned by CreateTenant for a tenant that already exists.
+	ErrTenantExists = errors.New("smartqueue: tenant already exists")
+	// ErrLeaseNotFound is returned for a lease token that is unknown, acked or already expired.
+	ErrLeaseNotFound = errors.New("smartqueue: lease not found")
+	// ErrInvalidShutdownMode is returned by Shutdown for an unknown mode, or ShutdownDrain without a drain function.
+	ErrInvalidShutdownMode = errors.New("smartqueue: invalid shutdown mode")
+	// ErrInvalidSnapshot is returned by Restore for input that is not a snapshot it can read.
+	ErrInvalidSnapshot = errors.New("smartqueue: invalid snapshot")
+	// ErrClosed is returned when the store has been stopped.
+	ErrClosed = errors.New("smartqueue: store closed")
+)
@@ -0,0 +1,198 @@
+package smartqueue
+
+import (
+	"container/list"
+	"time"
+)
+
+// EvictionPolicy decides what happens when a tenant queue is full.
+// The zero value defers to the store-wide policy.
+type EvictionPolicy int
+
+const (
+	// EvictOldest removes the oldest item of the lowest priority level to make room for the new item.
+	// When no item is ready, it removes the delayed item used least recently.
+	EvictOldest EvictionPolicy = iota + 1
+	// RejectNew keeps the queue as it is and rejects the new item with ErrCapacityReached.
+	RejectNew
+	// EvictSoonestExpiry removes the item closest to its expiry time.
+	EvictSoonestExpiry
+	// EvictLRU removes the item that was enqueued or read least recently.
+	EvictLRU
+	// EvictLFU removes the item that was read the fewest times, the least recently used one on ties.
+	EvictLFU
+	// Block waits until space frees up or the context passed to EnqueueContext is done.
+	Block
+)
+
+func (p EvictionPolicy) String() string {
+	switch p {
+	case EvictOldest:
+		return "evict_oldest"
+	case RejectNew:
+		return "reject_new"
+	case EvictSoonestExpiry:
+		return "evict_soonest_expiry"
+	case EvictLRU:
+		return "evict_lru"
+	case EvictLFU:
+		return "evict_lfu"
+	case Block:
+		return "block"
+	default:
+		return "default"
+	}
+}
+
+// evict removes one item from a full tenant store according to its eviction policy.
+// Policies that never evict fall back to the oldest item, which is what a capacity shrink needs.
+// Caller must hold tenantSpecificOrderedStore.mu
+func (t *tenantTTLStore[K, V]) evict(tenantId string, tenantSpecificOrderedStore *orderedStore[K, V]) bool {
+	key, ok := t.victim(tenantSpecificOrderedStore)
+	if !ok {
+		return false
+	}
+
+	t.removeInternal(tenantSpecificOrderedStore, key, Evicted)
+	t.metrics.evicted.Add(1)
+	t.cfg.logger.Debug("smartqueue: evicted item for capacity", "tenant", tenantId,
+		"policy", tenantSpecificOrderedStore.evictionPolicy.String(),
+		"capacity", tenantSpecificOrderedStore.capacity)
+
+	if listener := t.cfg.evictionListener; listener != nil {
+		tenantSpecificOrderedStore.pending = append(tenantSpecificOrderedStore.pending, func() {
+			listener(tenantId, key)
+		})
+	}
+	return true
+}
+
+// victim picks the key to evict. Leased items are in flight and never picked.
+// Every policy finds its victim from an index, visiting only the leased items it skips.
+// Caller must hold tenantSpecificOrderedStore.mu
+func (t *tenantTTLStore[K, V]) victim(tenantSpecificOrderedStore *orderedStore[K, V]) (key K, ok bool) {
+	access := tenantSpecificOrderedStore.access
+	switch tenantSpecificOrderedStore.evictionPolicy {
+	case EvictSoonestExpiry:
+		if e := soonestExpiring(tenantSpecificOrderedStore); e != nil {
+			return e.id, true
+		}
+		// persisted items have no expiry and go last
+		return unleased(tenantSpecificOrderedStore, access.recent)
+	case EvictLRU:
+		return unleased(tenantSpecificOrderedStore, access.recent)
+	case EvictLFU:
+		for b := access.buckets.Front(); b != nil; b = b.Next() {
+			if key, ok := unleased(tenantSpecificOrderedStore, b.Value.(*hitBucket).items); ok {
+				return key, true
+			}
+		}
+		return key, false
+	default:
+		if front := tenantSpecificOrderedStore.order.Lowest(); front != nil {
+			return front.Value.(K), true
+		}
+		// nothing is ready, so fall back to the delayed item used least recently
+		return unleased(tenantSpecificOrderedStore, access.recent)
+	}
+}
+
+// unleased returns the first key of items that is not leased.
+// Caller must hold tenantSpecificOrderedStore.mu
+func unleased[K comparable, V any](tenantSpecificOrderedStore *orderedStore[K, V], items *list.List) (key K, ok bool) {
+	for elem := items.Front(); elem != nil; elem = elem.Next() {
+		if key := elem.Value.(K); !tenantSpecificOrderedStore.entryMap[key].leased() {
+			return key, true
+		}
+	}
+	return key, false
+}
+
+// soonestExpiring returns the unleased entry that expires first, or nil when every such entry is persisted.
+// The expiry heap also holds lease deadlines and the TTLs of leased items, so it is walked in order
+// from its top, which visits only the items it skips.
+// Caller must hold tenantSpecificOrderedStore.mu
+func soonestExpiring[K comparable, V any](tenantSpecificOrderedStore *orderedStore[K, V]) *entry[K, V] {
+	for item := range tenantSpecificOrderedStore.expiryListHeap.ascending(time.Time{}) {
+		if e := tenantSpecificOrderedStore.entryMap[item.key]; e.ttlItem == item && !e.leased() {
+			return e
+		}
+	}
+	return nil
+}
+
+// accessOrder indexes the entries of a tenant for EvictLRU and EvictLFU. recent holds every key,
+// least recently used first; buckets group the keys by hit count, fewest first, each least recently used first.
+type accessOrder[K comparable, V any] struct {
+	recent  *list.List
+	buckets *list.List
+}
+
+type hitBucket struct {
+	hits  int64
+	items *list.List
+}
+
+func newAccessOrder[K comparable, V any]() *accessOrder[K, V] {
+	return &accessOrder[K, V]{recent: list.New(), buckets: list.New()}
+}
+
+// add indexes a new entry, which has no hits yet.
+func (a *accessOrder[K, V]) add(e *entry[K, V]) {
+	e.recentElem = a.recent.PushBack(e.id)
+	front := a.buckets.Front()
+	if front == nil || front.Value.(*hitBucket).hits != e.hits {
+		front = a.buckets.PushFront(&hitBucket{hits: e.hits, items: list.New()})
+	}
+	e.bucket = front
+	e.hitElem = front.Value.(*hitBucket).items.PushBack(e.id)
+}
+
+// touch moves e behind the others and into the bucket of its hits, which have just gone up by one.
+func (a *accessOrder[K, V]) touch(e *entry[K, V]) {
+	a.recent.MoveToBack(e.recentElem)
+	old := e.bucket
+	next := old.Next()
+	if b := old.Value.(*hitBucket); b.items.Len() == 1 && (next == nil || next.Value.(*hitBucket).hits != e.hits) {
+		// e is alone in its bucket, which takes the new count in place
+		b.hits = e.hits
+		return
+	}
+	if next == nil || next.Value.(*hitBucket).hits != e.hits {
+		next = a.buckets.InsertAfter(&hitBucket{hits: e.hits, items: list.New()}, old)
+	}
+	a.leave(e)
+	e.bucket = next
+	e.hitElem = next.Value.(*hitBucket).items.PushBack(e.id)
+}
+
+func (a *accessOrder[K, V]) remove(e *entry[K, V]) {
+	if e.recentElem == nil {
+		return
+	}
+	a.recent.Remove(e.recentElem)
+	a.leave(e)
+	e.recentElem, e.bucket, e.hitElem = nil, nil, nil
+}
+
+// leave takes e out of its bucket, dropping the bucket once empty.
+func (a *accessOrder[K, V]) leave(e *entry[K, V]) {
+	b := e.bucket.Value.(*hitBucket)
+	b.items.Remove(e.hitElem)
+	if b.items.Len() == 0 {
+		a.buckets.Remove(e.bucket)
+	}
+}
+
+func (a *accessOrder[K, V]) Init() {
+	a.recent.Init()
+	a.buckets.Init()
+}
+
+// touchAccess records a read or write of e for the LRU and LFU policies.
+// Caller must hold os.mu
+func (os *orderedStore[K, V]) touchAccess(e *entry[K, V], now time.Time) {
+	e.lastAccess = now
+	e.hits++
+	os.access.touch(e)
+}
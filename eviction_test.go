@@ -0,0 +1,161 @@
+package smartqueue
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTenantTTLStoreEvictionPolicies(t *testing.T) {
+	mockCallback := ExpiryCallback[int64, any](func(tenantId string, key int64) {})
+
+	tests := []struct {
+		name        string
+		policy      EvictionPolicy
+		setup       func(store *tenantTTLStore[int64, any])
+		wantErr     error
+		wantEvicted int64
+		wantStored  bool
+	}{
+		{
+			name:        "EvictOldest removes the front",
+			policy:      EvictOldest,
+			setup:       func(store *tenantTTLStore[int64, any]) {},
+			wantEvicted: 1,
+			wantStored:  true,
+		},
+		{
+			name:   "EvictOldest falls back to delayed items",
+			policy: EvictOldest,
+			setup: func(store *tenantTTLStore[int64, any]) {
+				store.Lease("t0001", time.Minute)
+				store.Remove("t0001", 2)
+				store.Remove("t0001", 3)
+				store.EnqueueAfter("t0001", 5, "five", time.Hour, mockCallback, time.Second)
+				store.EnqueueAfter("t0001", 6, "six", time.Hour, mockCallback, time.Second)
+			},
+			wantEvicted: 5,
+			wantStored:  true,
+		},
+		{
+			name:        "RejectNew keeps existing items",
+			policy:      RejectNew,
+			setup:       func(store *tenantTTLStore[int64, any]) {},
+			wantErr:     ErrCapacityReached,
+			wantEvicted: 0,
+			wantStored:  false,
+		},
+		{
+			name:        "EvictSoonestExpiry removes the item closest to expiry",
+			policy:      EvictSoonestExpiry,
+			setup:       func(store *tenantTTLStore[int64, any]) {},
+			wantEvicted: 2,
+			wantStored:  true,
+		},
+		{
+			name:   "EvictLRU removes the least recently used",
+			policy: EvictLRU,
+			setup: func(store *tenantTTLStore[int64, any]) {
+				store.Pop("t0001", 1)
+				store.Pop("t0001", 2)
+			},
+			wantEvicted: 3,
+			wantStored:  true,
+		},
+		{
+			name:   "EvictLFU removes the least frequently used",
+			policy: EvictLFU,
+			setup: func(store *tenantTTLStore[int64, any]) {
+				store.Pop("t0001", 1)
+				store.Pop("t0001", 1)
+				store.Pop("t0001", 3)
+			},
+			wantEvicted: 2,
+			wantStored:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var evicted atomic.Int64
+			store := NewTenantStore[int64, any](
+				WithCapacity(3),
+				WithEvictionPolicy(tt.policy),
+				WithEvictionListener(func(tenantId string, key any) {
+					evicted.Store(key.(int64))
+				}),
+			).(*tenantTTLStore[int64, any])
+			defer store.Stop()
+
+			store.Enqueue("t0001", 1, "one", mockCallback, 3*time.Second)
+			store.Enqueue("t0001", 2, "two", mockCallback, 1*time.Second)
+			store.Enqueue("t0001", 3, "three", mockCallback, 2*time.Second)
+			tt.setup(store)
+
+			capacityReached, err := store.EnqueueContext(context.Background(), "t0001", 4, "four", mockCallback, 5*time.Second)
+			if !capacityReached {
+				t.Errorf("%s: expected capacityReached", tt.name)
+			}
+			if err != tt.wantErr {
+				t.Errorf("%s: expected err=%v, got %v", tt.name, tt.wantErr, err)
+			}
+			if got := evicted.Load(); got != tt.wantEvicted {
+				t.Errorf("%s: expected evicted key=%d, got %d", tt.name, tt.wantEvicted, got)
+			}
+			if _, ok := store.Pop("t0001", 4); ok != tt.wantStored {
+				t.Errorf("%s: expected stored=%v, got %v", tt.name, tt.wantStored, ok)
+			}
+		})
+	}
+}
+
+func TestTenantTTLStoreBlockPolicy(t *testing.T) {
+	mockCallback := ExpiryCallback[int64, any](func(tenantId string, key int64) {})
+
+	t.Run("Context deadline while full", func(t *testing.T) {
+		store := NewTenantStore[int64, any](WithCapacity(1), WithEvictionPolicy(Block))
+		defer store.Stop()
+		store.Enqueue("t0001", 1, "one", mockCallback, 5*time.Second)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err := store.EnqueueContext(ctx, "t0001", 2, "two", mockCallback, 5*time.Second)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected deadline exceeded, got %v", err)
+		}
+	})
+
+	t.Run("Unblocked by Dequeue", func(t *testing.T) {
+		store := NewTenantStore[int64, any](WithCapacity(1), WithEvictionPolicy(Block))
+		defer store.Stop()
+		store.Enqueue("t0001", 1, "one", mockCallback, 5*time.Second)
+
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			store.Dequeue("t0001")
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		if _, err := store.EnqueueContext(ctx, "t0001", 2, "two", mockCallback, 5*time.Second); err != nil {
+			t.Fatalf("expected enqueue to succeed once space freed, got %v", err)
+		}
+		if _, ok := store.Pop("t0001", 2); !ok {
+			t.Errorf("expected key 2 to be stored")
+		}
+	})
+
+	t.Run("Updating an existing key never blocks", func(t *testing.T) {
+		store := NewTenantStore[int64, any](WithCapacity(1), WithEvictionPolicy(Block))
+		defer store.Stop()
+		store.Enqueue("t0001", 1, "one", mockCallback, 5*time.Second)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		if _, err := store.EnqueueContext(ctx, "t0001", 1, "updated", mockCallback, 5*time.Second); err != nil {
+			t.Errorf("expected update to succeed, got %v", err)
+		}
+	})
+}
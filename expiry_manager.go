@@ -1,25 +1,109 @@
 package smartqueue
 
-import "time"
+import (
+	"container/heap"
+	"iter"
+	"time"
+)
 
-type expiry struct {
+// expiry is a timer of a single entry: its TTL, its lease deadline or its ready time.
+// Each entry points at its own items, so they are fixed or removed in place and the heaps never hold stale items.
+type expiry[K comparable] struct {
 	tenantId   string
-	key        int64
+	key        K
 	expiration time.Time
+	// position in the heap, kept up to date by expiryList; -1 once popped or removed
+	index int
 }
 
-type expiryList []expiry
+type expiryList[K comparable] []*expiry[K]
 
-func (e expiryList) Len() int           { return len(e) }
-func (e expiryList) Less(i, j int) bool { return e[i].expiration.Before(e[j].expiration) }
-func (e expiryList) Swap(i, j int)      { e[i], e[j] = e[j], e[i] }
+func (e expiryList[K]) Len() int           { return len(e) }
+func (e expiryList[K]) Less(i, j int) bool { return e[i].expiration.Before(e[j].expiration) }
+func (e expiryList[K]) Swap(i, j int) {
+	e[i], e[j] = e[j], e[i]
+	e[i].index = i
+	e[j].index = j
+}
 
-func (e *expiryList) Push(x any) { *e = append(*e, x.(expiry)) }
+func (e *expiryList[K]) Push(x any) {
+	item := x.(*expiry[K])
+	item.index = len(*e)
+	*e = append(*e, item)
+}
 
-func (e *expiryList) Pop() any {
+func (e *expiryList[K]) Pop() any {
 	old := *e
 	n := len(old)
 	x := old[n-1]
+	old[n-1] = nil
+	x.index = -1
 	*e = old[:n-1]
 	return x
 }
+
+// set moves item to at, or pushes a new item for key when item is nil, and returns it.
+func (e *expiryList[K]) set(item *expiry[K], tenantId string, key K, at time.Time) *expiry[K] {
+	if item != nil && item.index >= 0 {
+		item.expiration = at
+		heap.Fix(e, item.index)
+		return item
+	}
+	item = &expiry[K]{tenantId: tenantId, key: key, expiration: at}
+	heap.Push(e, item)
+	return item
+}
+
+// remove takes item out of the heap. Nil and already popped items are ignored.
+func (e *expiryList[K]) remove(item *expiry[K]) {
+	if item != nil && item.index >= 0 {
+		heap.Remove(e, item.index)
+	}
+}
+
+// top reports whether item is the earliest one in the heap.
+func (e expiryList[K]) top(item *expiry[K]) bool {
+	return len(e) > 0 && e[0] == item
+}
+
+// ascending yields the items of the heap in expiry order, without popping them, by walking it best-first.
+// Items expiring before from are not yielded, though the walk still visits them.
+func (e expiryList[K]) ascending(from time.Time) iter.Seq[*expiry[K]] {
+	return func(yield func(*expiry[K]) bool) {
+		walk := &heapWalk[K]{items: e}
+		if len(e) > 0 {
+			walk.indexes = append(walk.indexes, 0)
+		}
+		for walk.Len() > 0 {
+			i := heap.Pop(walk).(int)
+			if left := 2*i + 1; left < len(e) {
+				heap.Push(walk, left)
+			}
+			if right := 2*i + 2; right < len(e) {
+				heap.Push(walk, right)
+			}
+			if item := e[i]; !item.expiration.Before(from) && !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+// heapWalk is a min-heap of positions in items, for visiting items in expiry order without popping them.
+type heapWalk[K comparable] struct {
+	items   expiryList[K]
+	indexes []int
+}
+
+func (w *heapWalk[K]) Len() int { return len(w.indexes) }
+func (w *heapWalk[K]) Less(i, j int) bool {
+	return w.items[w.indexes[i]].expiration.Before(w.items[w.indexes[j]].expiration)
+}
+func (w *heapWalk[K]) Swap(i, j int) { w.indexes[i], w.indexes[j] = w.indexes[j], w.indexes[i] }
+func (w *heapWalk[K]) Push(x any)    { w.indexes = append(w.indexes, x.(int)) }
+
+func (w *heapWalk[K]) Pop() any {
+	last := w.indexes[len(w.indexes)-1]
+	w.indexes = w.indexes[:len(w.indexes)-1]
+	return last
+}
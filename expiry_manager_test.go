@@ -0,0 +1,88 @@
+package smartqueue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExpiryHeapHasNoStaleItems(t *testing.T) {
+	tests := []struct {
+		name      string
+		act       func(store Queue[int64, any])
+		wantItems int
+	}{
+		{
+			name: "Updates fix the item in place",
+			act: func(store Queue[int64, any]) {
+				for i := 0; i < 100; i++ {
+					store.Enqueue("t0001", 1, "value", nil, time.Duration(i+1)*time.Second)
+				}
+			},
+			wantItems: 2,
+		},
+		{
+			name: "Remove and Dequeue take the item out",
+			act: func(store Queue[int64, any]) {
+				store.Remove("t0001", 1)
+				store.Dequeue("t0001")
+			},
+			wantItems: 0,
+		},
+		{
+			name: "Leases share the heap until acked",
+			act: func(store Queue[int64, any]) {
+				lease, _ := store.Lease("t0001", time.Minute)
+				store.ExtendLease("t0001", lease.Token, 2*time.Minute)
+				store.Nack("t0001", lease.Token)
+				lease, _ = store.Lease("t0001", time.Minute)
+				store.Ack("t0001", lease.Token)
+			},
+			wantItems: 1,
+		},
+		{
+			name: "Persist removes the item",
+			act: func(store Queue[int64, any]) {
+				store.Persist("t0001", 2)
+			},
+			wantItems: 1,
+		},
+		{
+			name: "Batch updates fix the items in place",
+			act: func(store Queue[int64, any]) {
+				store.EnqueueBatch("t0001", []BatchItem[int64, any]{
+					{Key: 1, Value: "one", TTL: time.Hour},
+					{Key: 2, Value: "two", TTL: time.Hour},
+					{Key: 3, Value: "three", TTL: time.Hour},
+				})
+			},
+			wantItems: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewTenantStore[int64, any]()
+			defer store.Stop()
+
+			store.Enqueue("t0001", 1, "one", nil, time.Minute)
+			store.Enqueue("t0001", 2, "two", nil, time.Minute)
+			tt.act(store)
+
+			tenantStore, _ := store.(*tenantTTLStore[int64, any]).GetTenantOrderedMap("t0001")
+			tenantStore.mu.RLock()
+			defer tenantStore.mu.RUnlock()
+
+			if got := tenantStore.expiryListHeap.Len(); got != tt.wantItems {
+				t.Errorf("%s: expected %d heap items, got %d", tt.name, tt.wantItems, got)
+			}
+			for i, item := range tenantStore.expiryListHeap {
+				if item.index != i {
+					t.Errorf("%s: heap item %d has index %d", tt.name, i, item.index)
+				}
+				if i > 0 && item.expiration.Before(tenantStore.expiryListHeap[(i-1)/2].expiration) {
+					t.Errorf("%s: heap order broken at %d", tt.name, i)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package smartqueue
+
+import (
+	"container/list"
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// FairnessPolicy decides which tenant DequeueNext serves next.
+type FairnessPolicy int
+
+const (
+	// RoundRobin serves one item from each ready tenant in turn.
+	RoundRobin FairnessPolicy = iota + 1
+	// WeightedFair serves tenants in proportion to their weight, interleaving them by virtual finish time.
+	WeightedFair
+	// DeficitRoundRobin serves up to weight items from a tenant before moving on to the next one.
+	// Every item costs the same, so this is weighted round robin in bursts.
+	DeficitRoundRobin
+)
+
+func (p FairnessPolicy) String() string {
+	switch p {
+	case RoundRobin:
+		return "round_robin"
+	case WeightedFair:
+		return "weighted_fair"
+	case DeficitRoundRobin:
+		return "deficit_round_robin"
+	default:
+		return "unknown"
+	}
+}
+
+// fairQueue holds the tenants with ready items for DequeueNext.
+// Tenants are added when an item enters their order list and dropped lazily once found empty.
+// Lock order is the tenant mu first, then fairQueue.mu.
+type fairQueue[K comparable, V any] struct {
+	mu     sync.Mutex
+	policy FairnessPolicy
+	ready  *list.List
+	// notified when a tenant joins the ready list
+	tenantReady signal
+	// the virtual start time of the last item served under WeightedFair
+	virtualTime float64
+}
+
+func newFairQueue[K comparable, V any](policy FairnessPolicy) *fairQueue[K, V] {
+	return &fairQueue[K, V]{policy: policy, ready: list.New()}
+}
+
+// add puts a tenant on the ready list if it is not there yet.
+// Caller must hold tenantSpecificOrderedStore.mu
+func (f *fairQueue[K, V]) add(tenantSpecificOrderedStore *orderedStore[K, V]) {
+	if tenantSpecificOrderedStore.fairElem != nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	tenantSpecificOrderedStore.fairElem = f.ready.PushBack(tenantSpecificOrderedStore)
+	// a tenant coming back does not get credit for the time it was idle
+	tenantSpecificOrderedStore.virtualFinish = max(tenantSpecificOrderedStore.virtualFinish, f.virtualTime)
+	f.tenantReady.notify()
+}
+
+// remove takes a tenant off the ready list.
+// Caller must hold tenantSpecificOrderedStore.mu
+func (f *fairQueue[K, V]) remove(tenantSpecificOrderedStore *orderedStore[K, V]) {
+	if tenantSpecificOrderedStore.fairElem == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.ready.Remove(tenantSpecificOrderedStore.fairElem)
+	tenantSpecificOrderedStore.fairElem = nil
+	tenantSpecificOrderedStore.deficit = 0
+}
+
+// pick chooses the tenant to serve and charges it for one item.
+// Caller must hold f.mu
+func (f *fairQueue[K, V]) pick() *orderedStore[K, V] {
+	front := f.ready.Front()
+	if front == nil {
+		return nil
+	}
+
+	switch f.policy {
+	case WeightedFair:
+		var best *orderedStore[K, V]
+		var bestStart, bestFinish float64
+		for elem := front; elem != nil; elem = elem.Next() {
+			tenantSpecificOrderedStore := elem.Value.(*orderedStore[K, V])
+			start := max(tenantSpecificOrderedStore.virtualFinish, f.virtualTime)
+			finish := start + 1/float64(tenantSpecificOrderedStore.weight)
+			if best == nil || finish < bestFinish {
+				best, bestStart, bestFinish = tenantSpecificOrderedStore, start, finish
+			}
+		}
+		f.virtualTime = bestStart
+		best.virtualFinish = bestFinish
+		return best
+	case DeficitRoundRobin:
+		tenantSpecificOrderedStore := front.Value.(*orderedStore[K, V])
+		if tenantSpecificOrderedStore.deficit <= 0 {
+			tenantSpecificOrderedStore.deficit += tenantSpecificOrderedStore.weight
+		}
+		tenantSpecificOrderedStore.deficit--
+		if tenantSpecificOrderedStore.deficit <= 0 {
+			f.ready.MoveToBack(front)
+		}
+		return tenantSpecificOrderedStore
+	default:
+		f.ready.MoveToBack(front)
+		return front.Value.(*orderedStore[K, V])
+	}
+}
+
+// DequeueNext removes and returns the next item of any tenant, choosing the tenant by the store
+// fairness policy, so a shared pool of consumers can drain every tenant without knowing their IDs.
+// It waits until an item is ready or ctx is done, and returns ErrClosed when the store stops while waiting.
+func (t *tenantTTLStore[K, V]) DequeueNext(ctx context.Context) (tenantId string, key K, value V, err error) {
+	if tracer := t.cfg.tracer; tracer != nil {
+		var end func(attrs ...slog.Attr)
+		ctx, end = tracer.Start(ctx, SpanDequeue)
+		defer func() { end(dequeuedAttrs(tenantId, key, err)...) }()
+	}
+
+	for {
+		if t.closing() {
+			return tenantId, key, value, ErrClosed
+		}
+		t.fair.mu.Lock()
+		tenantSpecificOrderedStore := t.fair.pick()
+		if tenantSpecificOrderedStore == nil {
+			ready := t.fair.tenantReady.wait()
+			t.fair.mu.Unlock()
+			select {
+			case <-ctx.Done():
+				return tenantId, key, value, ctx.Err()
+			case <-t.stopCh:
+				return tenantId, key, value, ErrClosed
+			case <-ready:
+			}
+			continue
+		}
+		t.fair.mu.Unlock()
+
+		tenantSpecificOrderedStore.mu.Lock()
+		e, ok := t.dequeueLocked(tenantSpecificOrderedStore)
+		if tenantSpecificOrderedStore.order.Len() == 0 {
+			t.fair.remove(tenantSpecificOrderedStore)
+		}
+		t.unlock(tenantSpecificOrderedStore)
+
+		if ok {
+			return tenantSpecificOrderedStore.tenantId, e.id, e.value, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return tenantId, key, value, err
+		}
+	}
+}
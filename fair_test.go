@@ -0,0 +1,120 @@
+package smartqueue
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestTenantTTLStoreDequeueNext(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      FairnessPolicy
+		wantTenants []string
+	}{
+		{
+			name:        "RoundRobin ignores weights",
+			policy:      RoundRobin,
+			wantTenants: []string{"a", "b", "a", "b", "a", "b"},
+		},
+		{
+			name:        "WeightedFair interleaves by weight",
+			policy:      WeightedFair,
+			wantTenants: []string{"b", "a", "b", "b", "a", "b"},
+		},
+		{
+			name:        "DeficitRoundRobin serves weight items per turn",
+			policy:      DeficitRoundRobin,
+			wantTenants: []string{"a", "b", "b", "a", "b", "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewTenantStore[int64, any](
+				WithFairness(tt.policy),
+				WithTenantConfig("b", TenantConfig{Weight: 2}),
+			)
+			defer store.Stop()
+
+			for i := int64(0); i < 6; i++ {
+				store.Enqueue("a", i, "value", nil, time.Minute)
+				store.Enqueue("b", i, "value", nil, time.Minute)
+			}
+
+			var tenants []string
+			for range tt.wantTenants {
+				tenantId, _, _, err := store.DequeueNext(context.Background())
+				if err != nil {
+					t.Fatalf("%s: unexpected error %v", tt.name, err)
+				}
+				tenants = append(tenants, tenantId)
+			}
+			if !slices.Equal(tenants, tt.wantTenants) {
+				t.Errorf("%s: expected tenants %v, got %v", tt.name, tt.wantTenants, tenants)
+			}
+		})
+	}
+}
+
+func TestTenantTTLStoreDequeueNextSkipsEmptyTenants(t *testing.T) {
+	store := NewTenantStore[int64, any]()
+	defer store.Stop()
+
+	store.Enqueue("a", 1, "one", nil, time.Minute)
+	store.Enqueue("b", 1, "one", nil, time.Minute)
+	store.Dequeue("a")
+
+	if tenantId, key, _, err := store.DequeueNext(context.Background()); err != nil || tenantId != "b" || key != 1 {
+		t.Errorf("expected b/1, got %s/%d, %v", tenantId, key, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, _, _, err := store.DequeueNext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestTenantTTLStoreDequeueNextWaits(t *testing.T) {
+	tests := []struct {
+		name    string
+		act     func(store Queue[int64, any])
+		wantErr error
+	}{
+		{
+			name: "Item enqueued",
+			act: func(store Queue[int64, any]) {
+				store.Enqueue("t0001", 1, "one", nil, time.Minute)
+			},
+		},
+		{
+			name:    "Store stopped",
+			act:     func(store Queue[int64, any]) { store.Stop() },
+			wantErr: ErrClosed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewTenantStore[int64, any]()
+			store.CreateTenant("t0001")
+
+			go func() {
+				time.Sleep(20 * time.Millisecond)
+				tt.act(store)
+			}()
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			if _, _, _, err := store.DequeueNext(ctx); !errors.Is(err, tt.wantErr) {
+				t.Errorf("%s: expected %v, got %v", tt.name, tt.wantErr, err)
+			}
+			if tt.wantErr == nil {
+				store.Stop()
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package smartqueue
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+)
+
+// Lease is an item handed to a consumer without removing it from the queue.
+// Until Deadline the item is hidden; then it goes back to the front of the tenant queue
+// unless it was acknowledged with Ack.
+type Lease[K comparable, V any] struct {
+	Token    string
+	TenantID string
+	Key      K
+	Value    V
+	Deadline time.Time
+	// Deliveries counts the leases of the item, this one included.
+	Deliveries int
+}
+
+// Lease hides the front item of the tenant for the visibility duration and returns it with a lease token.
+// Expired items met on the way are dropped as by Dequeue.
+func (t *tenantTTLStore[K, V]) Lease(tenantId string, visibility time.Duration) (lease Lease[K, V], ok bool) {
+	if tracer := t.cfg.tracer; tracer != nil {
+		_, end := tracer.Start(context.Background(), SpanLease, slog.String(AttrTenant, tenantId))
+		defer func() {
+			if ok {
+				end(hitOutcome(ok), slog.Any(AttrKey, lease.Key))
+			} else {
+				end(hitOutcome(ok))
+			}
+		}()
+	}
+
+	tenantSpecificOrderedStore, ok := t.GetTenantOrderedMap(tenantId)
+	if !ok {
+		return lease, false
+	}
+
+	tenantSpecificOrderedStore.mu.Lock()
+	defer t.unlock(tenantSpecificOrderedStore)
+
+	now := t.cfg.clock.Now()
+	for front := t.next(tenantSpecificOrderedStore, now); front != nil; front = t.next(tenantSpecificOrderedStore, now) {
+		key := front.Value.(K)
+		e := tenantSpecificOrderedStore.entryMap[key]
+		if e.expired(now) {
+			t.removeInternal(tenantSpecificOrderedStore, key, Expired)
+			continue
+		}
+
+		tenantSpecificOrderedStore.order.Remove(front, e.priority)
+		e.element = nil
+		e.deliveries++
+		e.leaseToken = tenantId + "-" + strconv.FormatUint(t.leaseSeq.Add(1), 36)
+		tenantSpecificOrderedStore.leases[e.leaseToken] = key
+		tenantSpecificOrderedStore.lastActive = now
+		t.setLeaseDeadline(tenantSpecificOrderedStore, e, now.Add(visibility))
+
+		return Lease[K, V]{
+			Token:      e.leaseToken,
+			TenantID:   tenantId,
+			Key:        key,
+			Value:      e.value,
+			Deadline:   e.leaseDeadline,
+			Deliveries: e.deliveries,
+		}, true
+	}
+	return lease, false
+}
+
+// Ack completes a lease and removes its item for good.
+func (t *tenantTTLStore[K, V]) Ack(tenantId string, token string) error {
+	return t.withLease(SpanAck, tenantId, token, func(tenantSpecificOrderedStore *orderedStore[K, V], e *entry[K, V]) {
+		t.removeInternal(tenantSpecificOrderedStore, e.id)
+	})
+}
+
+// Nack gives a leased item back straight away, at the front of the tenant queue.
+func (t *tenantTTLStore[K, V]) Nack(tenantId string, token string) error {
+	return t.withLease(SpanNack, tenantId, token, func(tenantSpecificOrderedStore *orderedStore[K, V], e *entry[K, V]) {
+		t.requeueLeased(tenantSpecificOrderedStore, e)
+	})
+}
+
+// ExtendLease moves the deadline of a lease to visibility from now.
+func (t *tenantTTLStore[K, V]) ExtendLease(tenantId string, token string, visibility time.Duration) error {
+	return t.withLease(SpanExtendLease, tenantId, token, func(tenantSpecificOrderedStore *orderedStore[K, V], e *entry[K, V]) {
+		t.setLeaseDeadline(tenantSpecificOrderedStore, e, t.cfg.clock.Now().Add(visibility))
+	})
+}
+
+// withLease runs fn on the entry held by a live lease, traced as the given span.
+func (t *tenantTTLStore[K, V]) withLease(span string, tenantId string, token string,
+	fn func(tenantSpecificOrderedStore *orderedStore[K, V], e *entry[K, V])) (err error) {
+
+	var key K
+	if tracer := t.cfg.tracer; tracer != nil {
+		_, end := tracer.Start(context.Background(), span, slog.String(AttrTenant, tenantId))
+		defer func() {
+			if err == nil {
+				end(errorOutcome(err), slog.Any(AttrKey, key))
+			} else {
+				end(errorOutcome(err))
+			}
+		}()
+	}
+
+	if t.closing() {
+		return ErrClosed
+	}
+	tenantSpecificOrderedStore, ok := t.GetTenantOrderedMap(tenantId)
+	if !ok {
+		return ErrLeaseNotFound
+	}
+
+	tenantSpecificOrderedStore.mu.Lock()
+	defer t.unlock(tenantSpecificOrderedStore)
+
+	if key, ok = tenantSpecificOrderedStore.leases[token]; !ok {
+		return ErrLeaseNotFound
+	}
+	fn(tenantSpecificOrderedStore, tenantSpecificOrderedStore.entryMap[key])
+	return nil
+}
+
+// setLeaseDeadline schedules the lease timer of e on the tenant expiry heap.
+// Caller must hold tenantSpecificOrderedStore.mu
+func (t *tenantTTLStore[K, V]) setLeaseDeadline(tenantSpecificOrderedStore *orderedStore[K, V], e *entry[K, V], deadline time.Time) {
+	e.leaseDeadline = deadline
+	e.leaseItem = tenantSpecificOrderedStore.expiryListHeap.set(e.leaseItem, tenantSpecificOrderedStore.tenantId, e.id, deadline)
+	if tenantSpecificOrderedStore.expiryListHeap.top(e.leaseItem) {
+		t.expiryScheduled(tenantSpecificOrderedStore, deadline)
+	}
+}
+
+// requeueLeased ends the lease of e and puts the item back at the front of the tenant queue,
+// or into the dead-letter store once it has been delivered the maximum number of times.
+// Caller must hold tenantSpecificOrderedStore.mu
+func (t *tenantTTLStore[K, V]) requeueLeased(tenantSpecificOrderedStore *orderedStore[K, V], e *entry[K, V]) {
+	if t.cfg.maxDeliveries > 0 && e.deliveries >= t.cfg.maxDeliveries {
+		t.deadLetter(tenantSpecificOrderedStore, e)
+		return
+	}
+	delete(tenantSpecificOrderedStore.leases, e.leaseToken)
+	tenantSpecificOrderedStore.expiryListHeap.remove(e.leaseItem)
+	e.leaseItem = nil
+	e.leaseToken = ""
+	e.leaseDeadline = time.Time{}
+	e.element, e.seq = tenantSpecificOrderedStore.order.PushFront(e.id, e.priority)
+	tenantSpecificOrderedStore.itemAdded.notify()
+	t.fair.add(tenantSpecificOrderedStore)
+}
@@ -0,0 +1,132 @@
+package smartqueue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/smartqueue/smartqueuetest"
+)
+
+func TestTenantTTLStoreLease(t *testing.T) {
+	tests := []struct {
+		name      string
+		settle    func(store Queue[int64, any], lease Lease[int64, any]) error
+		wait      time.Duration
+		wantErr   error
+		wantFront int64
+		wantLen   int
+	}{
+		{
+			name: "Ack removes the item",
+			settle: func(store Queue[int64, any], lease Lease[int64, any]) error {
+				return store.Ack("t0001", lease.Token)
+			},
+			wantFront: 2,
+			wantLen:   1,
+		},
+		{
+			name: "Nack returns the item to the front",
+			settle: func(store Queue[int64, any], lease Lease[int64, any]) error {
+				return store.Nack("t0001", lease.Token)
+			},
+			wantFront: 1,
+			wantLen:   2,
+		},
+		{
+			name: "Expired lease returns the item to the front",
+			settle: func(store Queue[int64, any], lease Lease[int64, any]) error {
+				return nil
+			},
+			wait:      150 * time.Millisecond,
+			wantFront: 1,
+			wantLen:   2,
+		},
+		{
+			name: "ExtendLease keeps the item hidden",
+			settle: func(store Queue[int64, any], lease Lease[int64, any]) error {
+				return store.ExtendLease("t0001", lease.Token, time.Second)
+			},
+			wait:      150 * time.Millisecond,
+			wantFront: 2,
+			wantLen:   1,
+		},
+		{
+			name: "Unknown token",
+			settle: func(store Queue[int64, any], lease Lease[int64, any]) error {
+				return store.Ack("t0001", "unknown")
+			},
+			wantErr:   ErrLeaseNotFound,
+			wantFront: 2,
+			wantLen:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := smartqueuetest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+			store := NewTenantStore[int64, any](WithClock(clock), WithIdlePollInterval(10*time.Millisecond))
+			defer store.Stop()
+
+			store.Enqueue("t0001", 1, "one", nil, time.Minute)
+			store.Enqueue("t0001", 2, "two", nil, time.Minute)
+
+			lease, ok := store.Lease("t0001", 50*time.Millisecond)
+			if !ok || lease.Key != 1 || lease.Value != "one" || lease.Token == "" {
+				t.Fatalf("%s: unexpected lease %+v, %v", tt.name, lease, ok)
+			}
+			if _, ok := store.Pop("t0001", 1); ok {
+				t.Errorf("%s: leased item should be hidden from Pop", tt.name)
+			}
+
+			if err := tt.settle(store, lease); !errors.Is(err, tt.wantErr) {
+				t.Errorf("%s: expected error %v, got %v", tt.name, tt.wantErr, err)
+			}
+			// expired leases are reclaimed by the cleanup loop
+			clock.Advance(tt.wait)
+			ready := func() int {
+				stats, _ := store.Stats("t0001")
+				return stats.Ready
+			}
+			for deadline := time.Now().Add(time.Second); ready() < tt.wantLen && time.Now().Before(deadline); {
+				time.Sleep(time.Millisecond)
+			}
+
+			got := store.DequeueN("t0001", 10)
+			if len(got) != tt.wantLen || got[0].Key != tt.wantFront {
+				t.Errorf("%s: expected %d items starting with %d, got %+v", tt.name, tt.wantLen, tt.wantFront, got)
+			}
+		})
+	}
+}
+
+func TestTenantTTLStoreLeaseSettledTwice(t *testing.T) {
+	store := NewTenantStore[int64, any]()
+	defer store.Stop()
+
+	store.Enqueue("t0001", 1, "one", nil, time.Minute)
+	lease, _ := store.Lease("t0001", time.Minute)
+
+	if err := store.Ack("t0001", lease.Token); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if err := store.Nack("t0001", lease.Token); !errors.Is(err, ErrLeaseNotFound) {
+		t.Errorf("expected %v, got %v", ErrLeaseNotFound, err)
+	}
+	if _, ok := store.Lease("t0001", time.Minute); ok {
+		t.Errorf("expected an empty tenant")
+	}
+}
+
+func TestTenantTTLStoreLeasedItemsAreNotEvicted(t *testing.T) {
+	store := NewTenantStore[int64, any](WithCapacity(1))
+	defer store.Stop()
+
+	store.Enqueue("t0001", 1, "one", nil, time.Minute)
+	store.Lease("t0001", time.Minute)
+
+	if _, err := store.EnqueueContext(context.Background(), "t0001", 2, "two", nil, time.Minute); !errors.Is(err, ErrCapacityReached) {
+		t.Errorf("expected %v, got %v", ErrCapacityReached, err)
+	}
+}
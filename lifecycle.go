@@ -0,0 +1,165 @@
+package smartqueue
+
+import (
+	"container/heap"
+	"time"
+)
+
+const minReapInterval = 10 * time.Millisecond
+
+// CreateTenant registers a new tenant, optionally with its own limits.
+// It returns ErrTenantExists when the tenant already exists.
+func (t *tenantTTLStore[K, V]) CreateTenant(tenantId string, tenantConfig ...TenantConfig) error {
+	if len(tenantConfig) != 0 {
+		if err := tenantConfig[0].validate(); err != nil {
+			return err
+		}
+	}
+
+	t.tenantsMu.Lock()
+	defer t.tenantsMu.Unlock()
+
+	if t.closed {
+		return ErrClosed
+	}
+	if _, ok := t.tenantOrderedStore[tenantId]; ok {
+		return ErrTenantExists
+	}
+	if len(tenantConfig) != 0 {
+		t.cfg.tenantConfigs[tenantId] = tenantConfig[0]
+	}
+	t.addTenantLocked(tenantId)
+	return nil
+}
+
+// DeleteTenant removes a tenant with all of its items and its configuration.
+// When fireCallbacks is set, every remaining item fires its callback with Removed.
+func (t *tenantTTLStore[K, V]) DeleteTenant(tenantId string, fireCallbacks bool) bool {
+	t.tenantsMu.Lock()
+	tenantSpecificOrderedStore, ok := t.tenantOrderedStore[tenantId]
+	if ok {
+		delete(t.tenantOrderedStore, tenantId)
+		delete(t.cfg.tenantConfigs, tenantId)
+	}
+	t.tenantsMu.Unlock()
+
+	if !ok {
+		return false
+	}
+
+	tenantSpecificOrderedStore.mu.Lock()
+	defer t.unlock(tenantSpecificOrderedStore)
+	if fireCallbacks {
+		t.closeTenantStore(tenantSpecificOrderedStore, Removed)
+	} else {
+		t.closeTenantStore(tenantSpecificOrderedStore)
+	}
+	return true
+}
+
+// closeTenantStore empties a store that has been taken out of tenantOrderedStore and stops its expiry.
+// When a reason is given, every remaining item fires its callback with it.
+// Caller must hold tenantStore.mu
+func (t *tenantTTLStore[K, V]) closeTenantStore(tenantStore *orderedStore[K, V], reason ...Reason) {
+	if len(reason) != 0 {
+		for elem := range tenantStore.order.All() {
+			t.fire(tenantStore, tenantStore.entryMap[elem.Value.(K)], reason[0])
+		}
+		// leased and delayed items are out of the order list
+		for _, e := range tenantStore.entryMap {
+			if e.element == nil {
+				t.fire(tenantStore, e, reason[0])
+			}
+		}
+		if tenantStore.deadLetters != nil {
+			for _, e := range tenantStore.deadLetters.entryMap {
+				t.fire(tenantStore, e, reason[0])
+			}
+		}
+	}
+
+	clear(tenantStore.entryMap)
+	clear(tenantStore.leases)
+	tenantStore.deadLetters = nil
+	tenantStore.order.Init()
+	tenantStore.access.Init()
+	tenantStore.expiryListHeap = tenantStore.expiryListHeap[:0]
+	tenantStore.readyListHeap = tenantStore.readyListHeap[:0]
+	tenantStore.size.Store(0)
+	tenantStore.deleted = true
+	t.fair.remove(tenantStore)
+	close(tenantStore.stopCh)
+	// waiting enqueues and dequeues retry against the tenant's next store
+	tenantStore.spaceFreed.notify()
+	tenantStore.itemAdded.notify()
+
+	if t.scheduler != nil {
+		shard := t.scheduler.shardFor(tenantStore.tenantId)
+		shard.mu.Lock()
+		if tenantStore.schedIndex >= 0 {
+			heap.Remove(&shard.queue, tenantStore.schedIndex)
+		}
+		shard.mu.Unlock()
+	}
+}
+
+func (t *tenantTTLStore[K, V]) reapIdleTenantsLoop() {
+	defer t.wg.Done()
+
+	interval := max(t.cfg.idleTenantTimeout/2, minReapInterval)
+	for {
+		timer, stop := t.cfg.clock.NewTimer(interval)
+		select {
+		case <-t.stopCh:
+			stop()
+			return
+		case <-timer:
+			t.reapIdleTenants()
+		}
+	}
+}
+
+// reapIdleTenants deletes tenants that have been empty, with no waiting consumers, for longer than the idle timeout.
+// Candidates are collected under the read lock and then deleted one at a time, so tenant lookups and new tenants
+// are held up by a single tenant at most. A tenant whose lock is taken is in use and is left for the next tick.
+func (t *tenantTTLStore[K, V]) reapIdleTenants() {
+	now := t.cfg.clock.Now()
+	// Caller must hold tenantStore.mu
+	idle := func(tenantStore *orderedStore[K, V]) bool {
+		return len(tenantStore.entryMap) == 0 && tenantStore.deadLetters == nil && tenantStore.waiters == 0 &&
+			now.Sub(tenantStore.lastActive) >= t.cfg.idleTenantTimeout
+	}
+
+	var candidates []*orderedStore[K, V]
+	t.tenantsMu.RLock()
+	for _, tenantStore := range t.tenantOrderedStore {
+		if !tenantStore.mu.TryRLock() {
+			continue
+		}
+		if idle(tenantStore) {
+			candidates = append(candidates, tenantStore)
+		}
+		tenantStore.mu.RUnlock()
+	}
+	t.tenantsMu.RUnlock()
+
+	for _, tenantStore := range candidates {
+		t.tenantsMu.Lock()
+		// the tenant may have been deleted, re-created or used since it was collected
+		if t.tenantOrderedStore[tenantStore.tenantId] != tenantStore || !tenantStore.mu.TryLock() {
+			t.tenantsMu.Unlock()
+			continue
+		}
+		reaped := idle(tenantStore)
+		if reaped {
+			delete(t.tenantOrderedStore, tenantStore.tenantId)
+		}
+		t.tenantsMu.Unlock()
+
+		if reaped {
+			t.closeTenantStore(tenantStore)
+			t.cfg.logger.Debug("smartqueue: reaped idle tenant", "tenant", tenantStore.tenantId)
+		}
+		t.unlock(tenantStore)
+	}
+}
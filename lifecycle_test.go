@@ -0,0 +1,130 @@
+package smartqueue
+
+import (
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/smartqueue/smartqueuetest"
+)
+
+func TestTenantTTLStoreCreateTenant(t *testing.T) {
+	store := NewTenantStore[int64, any](WithCapacity(10))
+	defer store.Stop()
+
+	if err := store.CreateTenant("t0001", TenantConfig{Capacity: 2}); err != nil {
+		t.Fatalf("expected tenant to be created, got %v", err)
+	}
+	if err := store.CreateTenant("t0001"); err != ErrTenantExists {
+		t.Errorf("expected ErrTenantExists, got %v", err)
+	}
+	if err := store.CreateTenant("t0002", TenantConfig{MaxTTL: -1}); err != ErrInvalidTenantConfig {
+		t.Errorf("expected ErrInvalidTenantConfig, got %v", err)
+	}
+	if tenantConfig, ok := store.GetTenantConfig("t0001"); !ok || tenantConfig.Capacity != 2 {
+		t.Errorf("expected capacity 2, got %+v (ok=%v)", tenantConfig, ok)
+	}
+}
+
+func TestTenantTTLStoreDeleteTenant(t *testing.T) {
+	tests := []struct {
+		name          string
+		opts          []Option
+		fireCallbacks bool
+		wantRemoved   int32
+	}{
+		{name: "Suppress callbacks", fireCallbacks: false, wantRemoved: 0},
+		{name: "Fire callbacks", fireCallbacks: true, wantRemoved: 3},
+		{name: "Shared scheduler", opts: []Option{WithSharedScheduler(1)}, fireCallbacks: true, wantRemoved: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var removed atomic.Int32
+			callback := func(event Event[int64, any]) {
+				if event.Reason == Removed {
+					removed.Add(1)
+				}
+			}
+
+			clock := smartqueuetest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+			store := NewTenantStore[int64, any](append(tt.opts, WithClock(clock), WithTenantConfig("t0001", TenantConfig{Capacity: 5}))...)
+			defer store.Stop()
+
+			for i := int64(1); i <= 3; i++ {
+				store.Enqueue("t0001", i, i, callback, 50*time.Millisecond)
+			}
+			tenantStore, _ := store.GetTenantOrderedMap("t0001")
+
+			if !store.DeleteTenant("t0001", tt.fireCallbacks) {
+				t.Fatalf("%s: expected tenant to be deleted", tt.name)
+			}
+			if store.DeleteTenant("t0001", tt.fireCallbacks) {
+				t.Errorf("%s: expected second delete to report a missing tenant", tt.name)
+			}
+			if _, ok := store.GetTenantOrderedMap("t0001"); ok {
+				t.Errorf("%s: expected tenant to be gone", tt.name)
+			}
+
+			// items must not expire after the tenant is gone, so nothing is left to expire
+			clock.Advance(100 * time.Millisecond)
+			tenantStore.mu.RLock()
+			left, expiring := len(tenantStore.entryMap), tenantStore.expiryListHeap.Len()
+			tenantStore.mu.RUnlock()
+			if left != 0 || expiring != 0 {
+				t.Errorf("%s: expected the deleted tenant cleared, got %d items and %d expiring", tt.name, left, expiring)
+			}
+			if got := removed.Load(); got != tt.wantRemoved {
+				t.Errorf("%s: expected %d removed callbacks, got %d", tt.name, tt.wantRemoved, got)
+			}
+
+			// the tenant comes back without its deleted configuration
+			store.Enqueue("t0001", 1, 1, nil, time.Second)
+			if tenantConfig, _ := store.GetTenantConfig("t0001"); tenantConfig.Capacity != defaultCapacity {
+				t.Errorf("%s: expected the default capacity after re-creation, got %d", tt.name, tenantConfig.Capacity)
+			}
+		})
+	}
+}
+
+func TestTenantTTLStoreIdleTenantReaper(t *testing.T) {
+	clock := smartqueuetest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	store := NewTenantStore[int64, any](
+		WithClock(clock),
+		WithIdleTenantTimeout(50*time.Millisecond),
+		WithTenantConfig("idle", TenantConfig{Capacity: 7}),
+	)
+	defer store.Stop()
+
+	store.Enqueue("idle", 1, "value", nil, time.Hour)
+	store.Dequeue("idle")
+	store.Enqueue("busy", 1, "value", nil, time.Hour)
+	goroutines := runtime.NumGoroutine()
+
+	// the reaper and both cleanup loops are waiting on their timers
+	clock.BlockUntil(3)
+	clock.Advance(200 * time.Millisecond)
+	reaped := func() bool {
+		_, ok := store.GetTenantOrderedMap("idle")
+		return !ok && runtime.NumGoroutine() < goroutines
+	}
+	for deadline := time.Now().Add(time.Second); !reaped() && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+
+	if _, ok := store.GetTenantOrderedMap("idle"); ok {
+		t.Errorf("expected the empty idle tenant to be reaped")
+	}
+	if _, ok := store.GetTenantOrderedMap("busy"); !ok {
+		t.Errorf("expected the non-empty tenant to be kept")
+	}
+	if got := runtime.NumGoroutine(); got >= goroutines {
+		t.Errorf("expected the reaped tenant's cleanup goroutine to exit, goroutines %d -> %d", goroutines, got)
+	}
+
+	store.Enqueue("idle", 2, "value", nil, time.Hour)
+	if tenantConfig, _ := store.GetTenantConfig("idle"); tenantConfig.Capacity != 7 {
+		t.Errorf("expected a reaped tenant to keep its config, got capacity %d", tenantConfig.Capacity)
+	}
+}
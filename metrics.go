@@ -0,0 +1,162 @@
+package smartqueue
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const metricsUrl = `/metrics`
+
+var (
+	callbackBuckets  = []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5}
+	expiryLagBuckets = []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10, 60}
+
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+)
+
+// metrics holds the store-wide counters. Gauges are read from the tenants when scraped.
+type metrics struct {
+	enqueued         atomic.Uint64
+	dequeued         atomic.Uint64
+	popHits          atomic.Uint64
+	popMisses        atomic.Uint64
+	expired          atomic.Uint64
+	evicted          atomic.Uint64
+	callbackPanics   atomic.Uint64
+	callbacksDropped atomic.Uint64
+	callbackDuration *histogram
+	// expiryLag is how late an expiry fired after its expiry time
+	expiryLag *histogram
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		callbackDuration: newHistogram(callbackBuckets),
+		expiryLag:        newHistogram(expiryLagBuckets),
+	}
+}
+
+// histogram counts durations into fixed buckets, given as upper bounds in seconds.
+type histogram struct {
+	bounds []float64
+	// counts has one more slot than bounds, for durations above the last bound
+	counts []atomic.Uint64
+	sum    atomic.Int64
+	count  atomic.Uint64
+}
+
+func newHistogram(bounds []float64) *histogram {
+	return &histogram{bounds: bounds, counts: make([]atomic.Uint64, len(bounds)+1)}
+}
+
+func (h *histogram) observe(d time.Duration) {
+	seconds := d.Seconds()
+	i := 0
+	for i < len(h.bounds) && seconds > h.bounds[i] {
+		i++
+	}
+	h.counts[i].Add(1)
+	h.sum.Add(int64(d))
+	h.count.Add(1)
+}
+
+// write prints the histogram in the Prometheus text format, with cumulative buckets.
+func (h *histogram) write(w io.Writer, name, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", name, help, name)
+	var cumulative uint64
+	for i, bound := range h.bounds {
+		cumulative += h.counts[i].Load()
+		fmt.Fprintf(w, "%s_bucket{le=\"%s\"} %d\n", name, strconv.FormatFloat(bound, 'g', -1, 64), cumulative)
+	}
+	cumulative += h.counts[len(h.bounds)].Load()
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", name, cumulative)
+	fmt.Fprintf(w, "%s_sum %s\n", name, strconv.FormatFloat(time.Duration(h.sum.Load()).Seconds(), 'g', -1, 64))
+	fmt.Fprintf(w, "%s_count %d\n", name, h.count.Load())
+}
+
+func writeCounter(w io.Writer, name, help string, value uint64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", name, help, name, name, value)
+}
+
+// tenantGauges is what a scrape reads from one tenant under its lock.
+type tenantGauges struct {
+	tenantId    string
+	items       int64
+	capacity    int64
+	expiryHeap  int
+	readyHeap   int
+	deadLetters int
+}
+
+// handleMetrics serves the store metrics in the Prometheus text exposition format.
+func (t *tenantTTLStore[K, V]) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	t.writeMetrics(w)
+}
+
+func (t *tenantTTLStore[K, V]) writeMetrics(out io.Writer) {
+	w := bufio.NewWriter(out)
+	defer w.Flush()
+
+	m := t.metrics
+	writeCounter(w, "smartqueue_enqueued_total", "Items enqueued or updated.", m.enqueued.Load())
+	writeCounter(w, "smartqueue_dequeued_total", "Items dequeued.", m.dequeued.Load())
+	fmt.Fprintf(w, "# HELP smartqueue_pops_total Pop calls by result.\n# TYPE smartqueue_pops_total counter\n")
+	fmt.Fprintf(w, "smartqueue_pops_total{result=\"hit\"} %d\n", m.popHits.Load())
+	fmt.Fprintf(w, "smartqueue_pops_total{result=\"miss\"} %d\n", m.popMisses.Load())
+	writeCounter(w, "smartqueue_expired_total", "Items removed because their TTL ran out.", m.expired.Load())
+	writeCounter(w, "smartqueue_evicted_total", "Items evicted to make room in a full tenant.", m.evicted.Load())
+	writeCounter(w, "smartqueue_callback_panics_total", "Callbacks that panicked.", m.callbackPanics.Load())
+	writeCounter(w, "smartqueue_callbacks_dropped_total", "Callbacks dropped by a full dispatcher.", m.callbacksDropped.Load())
+	m.callbackDuration.write(w, "smartqueue_callback_duration_seconds", "Time spent running callbacks.")
+	m.expiryLag.write(w, "smartqueue_expiry_lag_seconds", "Delay between the expiry time of an item or lease and its expiry firing.")
+
+	var gauges []tenantGauges
+	for _, tenantId := range t.Tenants() {
+		tenantSpecificOrderedStore, ok := t.GetTenantOrderedMap(tenantId)
+		if !ok {
+			continue
+		}
+		tenantSpecificOrderedStore.mu.RLock()
+		g := tenantGauges{
+			tenantId:   tenantId,
+			items:      tenantSpecificOrderedStore.size.Load(),
+			capacity:   tenantSpecificOrderedStore.capacity,
+			expiryHeap: tenantSpecificOrderedStore.expiryListHeap.Len(),
+			readyHeap:  tenantSpecificOrderedStore.readyListHeap.Len(),
+		}
+		if tenantSpecificOrderedStore.deadLetters != nil {
+			g.deadLetters = len(tenantSpecificOrderedStore.deadLetters.entryMap)
+		}
+		tenantSpecificOrderedStore.mu.RUnlock()
+		gauges = append(gauges, g)
+	}
+
+	fmt.Fprintf(w, "# HELP smartqueue_tenants Existing tenants.\n# TYPE smartqueue_tenants gauge\nsmartqueue_tenants %d\n", len(gauges))
+	tenantGauge := func(name, help string, value func(g tenantGauges) int64) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+		for _, g := range gauges {
+			fmt.Fprintf(w, "%s{tenant=\"%s\"} %d\n", name, labelEscaper.Replace(g.tenantId), value(g))
+		}
+	}
+	tenantGauge("smartqueue_tenant_items", "Items stored by the tenant, leased and delayed ones included.",
+		func(g tenantGauges) int64 { return g.items })
+	tenantGauge("smartqueue_tenant_capacity", "Maximum number of items of the tenant.",
+		func(g tenantGauges) int64 { return g.capacity })
+	tenantGauge("smartqueue_tenant_dead_letters", "Items in the dead-letter store of the tenant.",
+		func(g tenantGauges) int64 { return int64(g.deadLetters) })
+
+	// heap items are removed together with their entry, so this counts no stale items
+	fmt.Fprintf(w, "# HELP smartqueue_tenant_heap_items Items in the timer heaps of the tenant.\n# TYPE smartqueue_tenant_heap_items gauge\n")
+	for _, g := range gauges {
+		tenantId := labelEscaper.Replace(g.tenantId)
+		fmt.Fprintf(w, "smartqueue_tenant_heap_items{tenant=\"%s\",heap=\"expiry\"} %d\n", tenantId, g.expiryHeap)
+		fmt.Fprintf(w, "smartqueue_tenant_heap_items{tenant=\"%s\",heap=\"ready\"} %d\n", tenantId, g.readyHeap)
+	}
+}
@@ -0,0 +1,79 @@
+package smartqueue
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/smartqueue/smartqueuetest"
+)
+
+func TestAdminMetrics(t *testing.T) {
+	clock := smartqueuetest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	store := NewTenantStore[int64, string](WithClock(clock),
+		WithTenantConfig("t0001", TenantConfig{Capacity: 2, EvictionPolicy: EvictOldest}))
+	defer store.Stop()
+
+	expired := make(chan int64, 1)
+	store.Enqueue("t0001", 1, "one", func(event Event[int64, string]) {
+		if event.Reason == Expired {
+			expired <- event.Key
+		}
+	}, time.Second)
+	store.Enqueue("t0001", 2, "two", nil, time.Hour)
+	store.Pop("t0001", 2)
+	store.Pop("t0001", 9)
+
+	// the expiry fires two seconds late
+	clock.Advance(3 * time.Second)
+	select {
+	case <-expired:
+	case <-time.After(time.Second):
+		t.Fatal("expected key 1 to expire")
+	}
+
+	store.Dequeue("t0001")
+	store.Enqueue("t0001", 3, "three", nil, time.Hour)
+	store.Enqueue("t0001", 4, "four", nil, time.Hour)
+	store.Enqueue("t0001", 5, "five", func(Event[int64, string]) { panic("boom") }, time.Hour)
+	store.Remove("t0001", 5)
+	store.Enqueue("t\"0002", 1, "one", nil, time.Hour)
+
+	rec := httptest.NewRecorder()
+	store.AdminHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/plain; version=0.0.4") {
+		t.Errorf("expected the Prometheus text format, got %q", got)
+	}
+
+	body := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE smartqueue_enqueued_total counter\nsmartqueue_enqueued_total 6\n",
+		"smartqueue_dequeued_total 1\n",
+		`smartqueue_pops_total{result="hit"} 1` + "\n",
+		`smartqueue_pops_total{result="miss"} 1` + "\n",
+		"smartqueue_expired_total 1\n",
+		"smartqueue_evicted_total 1\n",
+		"smartqueue_callback_panics_total 1\n",
+		"# TYPE smartqueue_callback_duration_seconds histogram\n",
+		"smartqueue_callback_duration_seconds_count 2\n",
+		`smartqueue_expiry_lag_seconds_bucket{le="1"} 0` + "\n",
+		`smartqueue_expiry_lag_seconds_bucket{le="5"} 1` + "\n",
+		`smartqueue_expiry_lag_seconds_bucket{le="+Inf"} 1` + "\n",
+		"smartqueue_expiry_lag_seconds_sum 2\n",
+		"smartqueue_tenants 2\n",
+		`smartqueue_tenant_items{tenant="t0001"} 1` + "\n",
+		`smartqueue_tenant_items{tenant="t\"0002"} 1` + "\n",
+		`smartqueue_tenant_capacity{tenant="t0001"} 2` + "\n",
+		`smartqueue_tenant_heap_items{tenant="t0001",heap="expiry"} 1` + "\n",
+		`smartqueue_tenant_heap_items{tenant="t0001",heap="ready"} 0` + "\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected metrics to contain %q, got:\n%s", want, body)
+		}
+	}
+}
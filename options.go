@@ -0,0 +1,227 @@
+package smartqueue
+
+import (
+	"log/slog"
+	"runtime"
+	"time"
+)
+
+const (
+	defaultCapacity         = 10000
+	defaultIdlePollInterval = 500 * time.Millisecond
+)
+
+// Option configures a store created by NewTenantStore or NewSmartQueue.
+type Option func(*config)
+
+type config struct {
+	capacity         int64
+	tenantConfigs    map[string]TenantConfig
+	defaultTTL       time.Duration
+	clock            Clock
+	evictionPolicy   EvictionPolicy
+	dispatcher       Dispatcher
+	evictionListener func(tenantId string, key any)
+	logger           *slog.Logger
+	idlePollInterval time.Duration
+	httpPort         int64
+	schedulerShards  int
+	// tenants empty and unused for this long are deleted; zero disables reaping
+	idleTenantTimeout time.Duration
+	// leased items given back this many times are dead-lettered; zero disables the limit
+	maxDeliveries int
+	// an item gains one priority level per interval waited; zero disables aging
+	priorityAging  time.Duration
+	fairnessPolicy FairnessPolicy
+	// nil disables tracing
+	tracer Tracer
+	codec  Codec
+}
+
+func newConfig(opts []Option) config {
+	cfg := config{
+		capacity:         defaultCapacity,
+		tenantConfigs:    make(map[string]TenantConfig),
+		clock:            realClock{},
+		evictionPolicy:   EvictOldest,
+		dispatcher:       NewSyncDispatcher(),
+		logger:           slog.New(slog.DiscardHandler),
+		idlePollInterval: defaultIdlePollInterval,
+		httpPort:         defaultPort,
+		fairnessPolicy:   RoundRobin,
+		codec:            NewJSONCodec(),
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// WithCapacity sets the maximum number of items held per tenant. Non-positive values are ignored.
+func WithCapacity(capacity int64) Option {
+	return func(c *config) {
+		if capacity > 0 {
+			c.capacity = capacity
+		}
+	}
+}
+
+// WithTenantCapacity overrides the capacity for a single tenant. Non-positive values are ignored.
+func WithTenantCapacity(tenantId string, capacity int64) Option {
+	return func(c *config) {
+		if capacity > 0 {
+			cfg := c.tenantConfigs[tenantId]
+			cfg.Capacity = capacity
+			c.tenantConfigs[tenantId] = cfg
+		}
+	}
+}
+
+// WithTenantConfig sets the configuration a tenant starts with. Zero fields fall back to the store-wide settings.
+func WithTenantConfig(tenantId string, tenantConfig TenantConfig) Option {
+	return func(c *config) {
+		c.tenantConfigs[tenantId] = tenantConfig
+	}
+}
+
+// WithDefaultTTL sets the TTL used when Enqueue is called with a non-positive ttl.
+func WithDefaultTTL(ttl time.Duration) Option {
+	return func(c *config) {
+		c.defaultTTL = ttl
+	}
+}
+
+// WithClock sets the clock used for expiry, leases, delays and idle tenant reaping.
+// Tests can pass a smartqueuetest.FakeClock to advance time without sleeping.
+func WithClock(clock Clock) Option {
+	return func(c *config) {
+		if clock != nil {
+			c.clock = clock
+		}
+	}
+}
+
+// WithEvictionPolicy sets how a full tenant queue makes room for new items.
+func WithEvictionPolicy(policy EvictionPolicy) Option {
+	return func(c *config) {
+		if policy != 0 {
+			c.evictionPolicy = policy
+		}
+	}
+}
+
+// WithCallbackExecutor sets the function used to run expiry callbacks.
+// By default callbacks run synchronously on the goroutine that expires the item.
+func WithCallbackExecutor(executor func(task func())) Option {
+	return func(c *config) {
+		if executor != nil {
+			c.dispatcher = executorDispatcher(executor)
+		}
+	}
+}
+
+// WithDispatcher sets how callbacks are delivered once the tenant lock is released.
+// The store closes the dispatcher when it stops.
+func WithDispatcher(dispatcher Dispatcher) Option {
+	return func(c *config) {
+		if dispatcher != nil {
+			c.dispatcher = dispatcher
+		}
+	}
+}
+
+// WithEvictionListener sets a function notified whenever an item is evicted to make room,
+// so evictions can be told apart from TTL expiries. It runs on the callback dispatcher.
+func WithEvictionListener(listener func(tenantId string, key any)) Option {
+	return func(c *config) {
+		c.evictionListener = listener
+	}
+}
+
+// WithLogger sets the logger used for store diagnostics. Logging is discarded by default.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *config) {
+		if logger != nil {
+			c.logger = logger
+		}
+	}
+}
+
+// WithIdlePollInterval sets how often an idle tenant cleanup loop checks for new items.
+func WithIdlePollInterval(interval time.Duration) Option {
+	return func(c *config) {
+		if interval > 0 {
+			c.idlePollInterval = interval
+		}
+	}
+}
+
+// WithSharedScheduler expires items of all tenants from a fixed number of goroutines instead of
+// starting one cleanup loop per tenant. Non-positive shards default to GOMAXPROCS.
+func WithSharedScheduler(shards int) Option {
+	return func(c *config) {
+		if shards <= 0 {
+			shards = runtime.GOMAXPROCS(0)
+		}
+		c.schedulerShards = shards
+	}
+}
+
+// WithIdleTenantTimeout deletes tenants that have been empty and unused for the given duration,
+// freeing their store and cleanup goroutine. Their TenantConfig is kept for when they come back.
+func WithIdleTenantTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.idleTenantTimeout = timeout
+	}
+}
+
+// WithMaxDeliveries sets how many times an item may be leased. An item whose last allowed lease
+// expires or is nacked moves to the dead-letter store of its tenant. Non-positive values disable the limit.
+func WithMaxDeliveries(deliveries int) Option {
+	return func(c *config) {
+		c.maxDeliveries = max(deliveries, 0)
+	}
+}
+
+// WithPriorityAging lets waiting items gain one priority level for every interval they have waited,
+// so low-priority items are not starved by a steady flow of higher-priority ones. Non-positive values disable aging.
+func WithPriorityAging(interval time.Duration) Option {
+	return func(c *config) {
+		c.priorityAging = max(interval, 0)
+	}
+}
+
+// WithFairness sets how DequeueNext shares consumers between tenants. The default is RoundRobin;
+// the weighted policies use TenantConfig.Weight.
+func WithFairness(policy FairnessPolicy) Option {
+	return func(c *config) {
+		if policy != 0 {
+			c.fairnessPolicy = policy
+		}
+	}
+}
+
+// WithHTTPPort sets the port used by RegisterHTTPHandlers when none is given.
+func WithHTTPPort(port int64) Option {
+	return func(c *config) {
+		if port > 0 {
+			c.httpPort = port
+		}
+	}
+}
+
+// WithTracer sets the Tracer called around store operations. Without one, tracing costs nothing.
+func WithTracer(tracer Tracer) Option {
+	return func(c *config) {
+		c.tracer = tracer
+	}
+}
+
+// WithCodec sets the Codec used by Snapshot and Restore for keys and values. The default is NewJSONCodec.
+func WithCodec(codec Codec) Option {
+	return func(c *config) {
+		if codec != nil {
+			c.codec = codec
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package smartqueue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/smartqueue/smartqueuetest"
+)
+
+func TestNewTenantStoreOptions(t *testing.T) {
+	mockCallback := ExpiryCallback[int64, any](func(tenantId string, key int64) {})
+
+	tests := []struct {
+		name         string
+		opts         []Option
+		tenantId     string
+		wantCapacity int64
+	}{
+		{
+			name:         "Default capacity",
+			opts:         nil,
+			tenantId:     "t0001",
+			wantCapacity: defaultCapacity,
+		},
+		{
+			name:         "WithCapacity",
+			opts:         []Option{WithCapacity(5)},
+			tenantId:     "t0001",
+			wantCapacity: 5,
+		},
+		{
+			name:         "Tenant capacity override",
+			opts:         []Option{WithCapacity(5), WithTenantCapacity("premium", 50)},
+			tenantId:     "premium",
+			wantCapacity: 50,
+		},
+		{
+			name:         "Tenant capacity override does not leak to other tenants",
+			opts:         []Option{WithCapacity(5), WithTenantCapacity("premium", 50)},
+			tenantId:     "free",
+			wantCapacity: 5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewTenantStore[int64, any](tt.opts...).(*tenantTTLStore[int64, any])
+			defer store.Stop()
+
+			store.Enqueue(tt.tenantId, 1, "value", mockCallback, time.Second)
+			tenantStore, ok := store.GetTenantOrderedMap(tt.tenantId)
+			if !ok {
+				t.Fatalf("%s: expected tenant %s to exist", tt.name, tt.tenantId)
+			}
+			if tenantStore.capacity != tt.wantCapacity {
+				t.Errorf("%s: expected capacity=%d, got %d", tt.name, tt.wantCapacity, tenantStore.capacity)
+			}
+		})
+	}
+}
+
+func TestWithDefaultTTLAndClock(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewTenantStore[int64, any](
+		WithDefaultTTL(time.Minute),
+		WithClock(smartqueuetest.NewFakeClock(now)),
+	).(*tenantTTLStore[int64, any])
+	defer store.Stop()
+
+	store.Enqueue("t0001", 1, "value", nil, 0)
+
+	tenantStore, _ := store.GetTenantOrderedMap("t0001")
+	tenantStore.mu.RLock()
+	e := tenantStore.entryMap[1]
+	tenantStore.mu.RUnlock()
+	if want := now.Add(time.Minute); !e.expiryTime.Equal(want) {
+		t.Errorf("expected expiry %v, got %v", want, e.expiryTime)
+	}
+}
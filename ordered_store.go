@@ -5,25 +5,110 @@ import (
 	"container/list"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
-type orderedStore struct {
+type orderedStore[K comparable, V any] struct {
 	mu             sync.RWMutex
-	entryMap       map[int64]*entry
+	tenantId       string
+	entryMap       map[K]*entry[K, V]
+	leases         map[string]K
 	capacity       int64
+	defaultTTL     time.Duration
+	maxTTL         time.Duration
+	evictionPolicy EvictionPolicy
 	size           atomic.Int64
-	order          *list.List
-	expiryListHeap expiryList
+	order          *priorityOrder
+	access         *accessOrder[K, V]
+	expiryListHeap expiryList[K]
+	readyListHeap  expiryList[K]
+	spaceFreed     signal
+	itemAdded      signal
+	// goroutines blocked in DequeueWait or DequeueAny on this tenant
+	waiters int
+	// wakes the tenant cleanup loop when an earlier expiration is pushed
+	wakeCh chan struct{}
+	// position in the shared scheduler, guarded by the scheduler shard mutex
+	schedAt    time.Time
+	schedIndex int
+	// set once the tenant is deleted; stopCh ends its cleanup loop
+	deleted    bool
+	stopCh     chan struct{}
+	lastActive time.Time
+	// callbacks queued under mu, dispatched by tenantTTLStore.unlock
+	pending []func()
+	// items over the delivery limit, guarded by mu and created on first use
+	deadLetters *orderedStore[K, V]
+	// share of DequeueNext; written under both mu and the fair queue mutex
+	weight int64
+	// position and accounting in the fair queue, guarded by mu and the fair queue mutex
+	fairElem      *list.Element
+	deficit       int64
+	virtualFinish float64
 }
 
-func newOrderedStore(cap int64) *orderedStore {
-	os := &orderedStore{
-		entryMap:       make(map[int64]*entry),
-		order:          list.New(),
-		expiryListHeap: expiryList{},
-		capacity:       cap,
+func newOrderedStore[K comparable, V any](tenantId string, cfg TenantConfig) *orderedStore[K, V] {
+	os := &orderedStore[K, V]{
+		tenantId:       tenantId,
+		entryMap:       make(map[K]*entry[K, V]),
+		leases:         make(map[string]K),
+		order:          newPriorityOrder(),
+		access:         newAccessOrder[K, V](),
+		expiryListHeap: expiryList[K]{},
+		readyListHeap:  expiryList[K]{},
+		capacity:       cfg.Capacity,
+		defaultTTL:     cfg.DefaultTTL,
+		maxTTL:         cfg.MaxTTL,
+		evictionPolicy: cfg.EvictionPolicy,
+		weight:         cfg.Weight,
+		wakeCh:         make(chan struct{}, 1),
+		stopCh:         make(chan struct{}),
+		schedIndex:     -1,
 	}
 
 	heap.Init(&os.expiryListHeap)
 	return os
 }
+
+// ttlFor applies the tenant default and maximum TTL to a requested ttl.
+func (os *orderedStore[K, V]) ttlFor(ttl time.Duration) time.Duration {
+	if ttl <= 0 && os.defaultTTL > 0 {
+		ttl = os.defaultTTL
+	}
+	if os.maxTTL > 0 && ttl > os.maxTTL {
+		ttl = os.maxTTL
+	}
+	return ttl
+}
+
+// expiryFor caps an expiry time set on e to the tenant maximum TTL, counted from when e is ready.
+// A zero exp persists the item and is kept.
+func (os *orderedStore[K, V]) expiryFor(e *entry[K, V], exp, now time.Time) time.Time {
+	if os.maxTTL <= 0 || exp.IsZero() {
+		return exp
+	}
+	if latest := e.ttlStart(now).Add(os.maxTTL); exp.After(latest) {
+		return latest
+	}
+	return exp
+}
+
+// signal is a broadcast notification guarded by the owning store's mutex.
+// Waiters receive from wait() until notify closes the channel.
+type signal struct {
+	ch chan struct{}
+}
+
+func (s *signal) wait() <-chan struct{} {
+	if s.ch == nil {
+		s.ch = make(chan struct{})
+	}
+	return s.ch
+}
+
+func (s *signal) notify() {
+	if s.ch != nil {
+		close(s.ch)
+		s.ch = nil
+	}
+}
@@ -0,0 +1,160 @@
+package smartqueue
+
+import (
+	"cmp"
+	"container/list"
+	"iter"
+	"slices"
+	"time"
+)
+
+// Priority orders the items of a tenant. Items with a higher priority are dequeued first;
+// items of the same priority keep FIFO order.
+type Priority int
+
+// DefaultPriority is the priority of items enqueued without one.
+const DefaultPriority Priority = 0
+
+type priorityLevel struct {
+	priority Priority
+	items    *list.List
+}
+
+// priorityOrder keeps one FIFO list per priority level, highest level first.
+// Element values are keys, as in a plain list. A level is dropped once it is empty,
+// so arbitrary priorities do not pile up empty levels.
+type priorityOrder struct {
+	levels []priorityLevel
+	len    int
+	// back and front number the pushes, so that within a level sequence numbers follow list order
+	back, front int64
+}
+
+func newPriorityOrder() *priorityOrder {
+	return &priorityOrder{}
+}
+
+func (o *priorityOrder) Len() int {
+	return o.len
+}
+
+// search returns the index of the level of the given priority, or where it would be inserted.
+func (o *priorityOrder) search(priority Priority) (int, bool) {
+	// levels are sorted highest first
+	return slices.BinarySearchFunc(o.levels, priority, func(l priorityLevel, p Priority) int {
+		return cmp.Compare(p, l.priority)
+	})
+}
+
+// level returns the list of the given priority, creating it if needed.
+func (o *priorityOrder) level(priority Priority) *list.List {
+	i, found := o.search(priority)
+	if !found {
+		o.levels = slices.Insert(o.levels, i, priorityLevel{priority: priority, items: list.New()})
+	}
+	return o.levels[i].items
+}
+
+// PushBack adds key behind the elements of its level and returns its element and sequence number.
+func (o *priorityOrder) PushBack(key any, priority Priority) (*list.Element, int64) {
+	o.len++
+	o.back++
+	return o.level(priority).PushBack(key), o.back
+}
+
+// PushFront adds key before the elements of its level and returns its element and sequence number.
+func (o *priorityOrder) PushFront(key any, priority Priority) (*list.Element, int64) {
+	o.len++
+	o.front--
+	return o.level(priority).PushFront(key), o.front
+}
+
+func (o *priorityOrder) Remove(elem *list.Element, priority Priority) {
+	i, found := o.search(priority)
+	if !found {
+		return
+	}
+	o.len--
+	items := o.levels[i].items
+	items.Remove(elem)
+	if items.Len() == 0 {
+		o.levels = slices.Delete(o.levels, i, i+1)
+	}
+}
+
+// Front returns the oldest element of the highest non-empty level.
+func (o *priorityOrder) Front() *list.Element {
+	for _, l := range o.levels {
+		if front := l.items.Front(); front != nil {
+			return front
+		}
+	}
+	return nil
+}
+
+// Lowest returns the oldest element of the lowest non-empty level.
+func (o *priorityOrder) Lowest() *list.Element {
+	for _, l := range slices.Backward(o.levels) {
+		if front := l.items.Front(); front != nil {
+			return front
+		}
+	}
+	return nil
+}
+
+// All yields every element in dequeue order, ignoring aging.
+func (o *priorityOrder) All() iter.Seq[*list.Element] {
+	return o.After(nil, DefaultPriority)
+}
+
+// After yields the elements behind elem, which has the given priority, in dequeue order.
+// A nil elem yields every element.
+func (o *priorityOrder) After(elem *list.Element, priority Priority) iter.Seq[*list.Element] {
+	return func(yield func(*list.Element) bool) {
+		for _, l := range o.levels {
+			start := l.items.Front()
+			if elem != nil {
+				if l.priority > priority {
+					continue
+				}
+				if l.priority == priority {
+					start = elem.Next()
+				}
+			}
+			for e := start; e != nil; e = e.Next() {
+				if !yield(e) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (o *priorityOrder) Init() {
+	o.levels = o.levels[:0]
+	o.len = 0
+}
+
+// next returns the element to dequeue. With aging enabled, an item gains one priority level
+// for every aging interval it has waited, so routine items are not starved by a steady flow of urgent ones.
+// Caller must hold tenantSpecificOrderedStore.mu
+func (t *tenantTTLStore[K, V]) next(tenantSpecificOrderedStore *orderedStore[K, V], now time.Time) *list.Element {
+	if t.cfg.priorityAging <= 0 {
+		return tenantSpecificOrderedStore.order.Front()
+	}
+
+	var best *list.Element
+	var bestScore int64
+	for _, l := range tenantSpecificOrderedStore.order.levels {
+		front := l.items.Front()
+		if front == nil {
+			continue
+		}
+		e := tenantSpecificOrderedStore.entryMap[front.Value.(K)]
+		score := int64(l.priority) + int64(now.Sub(e.enqueuedAt)/t.cfg.priorityAging)
+		if best == nil || score > bestScore {
+			best, bestScore = front, score
+		}
+	}
+	return best
+}
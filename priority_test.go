@@ -0,0 +1,107 @@
+package smartqueue
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/smartqueue/smartqueuetest"
+)
+
+func TestTenantTTLStorePriority(t *testing.T) {
+	type item struct {
+		key      int64
+		priority Priority
+	}
+
+	tests := []struct {
+		name     string
+		items    []item
+		aging    time.Duration
+		advance  time.Duration
+		wantKeys []int64
+	}{
+		{
+			name:     "Higher priority first, FIFO within a level",
+			items:    []item{{1, 0}, {2, 5}, {3, 0}, {4, 5}, {5, -1}},
+			wantKeys: []int64{2, 4, 1, 3, 5},
+		},
+		{
+			name:     "Extreme priorities",
+			items:    []item{{1, math.MaxInt}, {2, math.MinInt}, {3, 1}},
+			wantKeys: []int64{1, 3, 2},
+		},
+		{
+			name:     "Updating a key moves it to its new level",
+			items:    []item{{1, 0}, {2, 0}, {1, 1}},
+			wantKeys: []int64{1, 2},
+		},
+		{
+			name:     "Aging lets old items catch up",
+			items:    []item{{1, 0}, {2, 2}},
+			aging:    time.Second,
+			advance:  3 * time.Second,
+			wantKeys: []int64{1, 2},
+		},
+		{
+			name:     "Aging keeps level order for items of the same age",
+			items:    []item{{1, 0}, {2, 2}},
+			aging:    time.Second,
+			advance:  time.Second,
+			wantKeys: []int64{2, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := smartqueuetest.NewFakeClock(time.Now())
+			store := NewTenantStore[int64, any](
+				WithPriorityAging(tt.aging),
+				WithClock(clock),
+			)
+			defer store.Stop()
+
+			for _, it := range tt.items {
+				if _, err := store.EnqueueWithPriority("t0001", it.key, "value", it.priority, nil, time.Minute); err != nil {
+					t.Fatalf("%s: unexpected error %v", tt.name, err)
+				}
+				// the first item is the oldest, the rest were enqueued at the current time
+				clock.Advance(tt.advance)
+				tt.advance = 0
+			}
+
+			got := store.DequeueN("t0001", 10)
+			if len(got) != len(tt.wantKeys) {
+				t.Fatalf("%s: expected keys %v, got %+v", tt.name, tt.wantKeys, got)
+			}
+			for i := range got {
+				if got[i].Key != tt.wantKeys[i] {
+					t.Errorf("%s: expected keys %v, got %+v", tt.name, tt.wantKeys, got)
+					break
+				}
+			}
+			// emptied levels are dropped
+			tenantStore, _ := store.GetTenantOrderedMap("t0001")
+			tenantStore.mu.RLock()
+			levels := len(tenantStore.order.levels)
+			tenantStore.mu.RUnlock()
+			if levels != 0 {
+				t.Errorf("%s: expected no levels left, got %d", tt.name, levels)
+			}
+		})
+	}
+}
+
+func TestTenantTTLStorePriorityEviction(t *testing.T) {
+	store := NewTenantStore[int64, any](WithCapacity(2))
+	defer store.Stop()
+
+	store.EnqueueWithPriority("t0001", 1, "urgent", 1, nil, time.Minute)
+	store.EnqueueWithPriority("t0001", 2, "routine", 0, nil, time.Minute)
+	store.EnqueueWithPriority("t0001", 3, "urgent", 1, nil, time.Minute)
+
+	got := store.DequeueN("t0001", 10)
+	if len(got) != 2 || got[0].Key != 1 || got[1].Key != 3 {
+		t.Errorf("expected the routine item evicted, got %+v", got)
+	}
+}
@@ -1,13 +1,16 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"github.com/smartqueue"
+	"os"
+	"os/signal"
 	"time"
 )
 
 func main() {
-	store := smartqueue.NewTenantStore(1000)
+	store := smartqueue.NewSmartQueue(smartqueue.WithCapacity(1000))
 	defer store.Stop()
 
 	callback := func(tenantId string, key int64) {
@@ -40,4 +43,8 @@ func main() {
 		return
 	}
 
+	// the admin server runs in the background until interrupted
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	<-ctx.Done()
 }
@@ -0,0 +1,126 @@
+package smartqueue
+
+import (
+	"container/heap"
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+// expiryScheduler drives expiry for every tenant from a fixed number of goroutines.
+// Each shard keeps a heap of tenants ordered by their earliest expiration, on top of the
+// per-tenant expiryListHeap, so the cost no longer grows with the number of tenants.
+type expiryScheduler[K comparable, V any] struct {
+	shards []*schedulerShard[K, V]
+}
+
+type schedulerShard[K comparable, V any] struct {
+	mu     sync.Mutex
+	queue  tenantQueue[K, V]
+	wakeCh chan struct{}
+}
+
+func newExpiryScheduler[K comparable, V any](shards int) *expiryScheduler[K, V] {
+	s := &expiryScheduler[K, V]{
+		shards: make([]*schedulerShard[K, V], max(shards, 1)),
+	}
+	for i := range s.shards {
+		s.shards[i] = &schedulerShard[K, V]{
+			wakeCh: make(chan struct{}, 1),
+		}
+	}
+	return s
+}
+
+func (s *expiryScheduler[K, V]) shardFor(tenantId string) *schedulerShard[K, V] {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(tenantId))
+	return s.shards[h.Sum32()%uint32(len(s.shards))]
+}
+
+// schedule makes sure the tenant is visited no later than at.
+func (s *schedulerShard[K, V]) schedule(tenantStore *orderedStore[K, V], at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if tenantStore.schedIndex >= 0 {
+		if !at.Before(tenantStore.schedAt) {
+			return
+		}
+		tenantStore.schedAt = at
+		heap.Fix(&s.queue, tenantStore.schedIndex)
+	} else {
+		tenantStore.schedAt = at
+		heap.Push(&s.queue, tenantStore)
+	}
+
+	if s.queue[0] == tenantStore {
+		select {
+		case s.wakeCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (t *tenantTTLStore[K, V]) runSchedulerShard(shard *schedulerShard[K, V]) {
+	defer t.wg.Done()
+
+	for {
+		shard.mu.Lock()
+		var timer <-chan time.Time
+		stop := func() bool { return false }
+		if len(shard.queue) > 0 {
+			tenantStore := shard.queue[0]
+			delay := tenantStore.schedAt.Sub(t.cfg.clock.Now())
+			if delay <= 0 {
+				heap.Pop(&shard.queue)
+				shard.mu.Unlock()
+
+				tenantStore.mu.Lock()
+				if next, ok := t.expireDue(tenantStore, t.cfg.clock.Now()); ok {
+					shard.schedule(tenantStore, next)
+				}
+				t.unlock(tenantStore)
+				continue
+			}
+			timer, stop = t.cfg.clock.NewTimer(delay)
+		}
+		shard.mu.Unlock()
+
+		select {
+		case <-t.stopCh:
+			stop()
+			return
+		case <-shard.wakeCh:
+			stop()
+		case <-timer:
+		}
+	}
+}
+
+// tenantQueue is a heap of tenant stores ordered by schedAt.
+type tenantQueue[K comparable, V any] []*orderedStore[K, V]
+
+func (q tenantQueue[K, V]) Len() int           { return len(q) }
+func (q tenantQueue[K, V]) Less(i, j int) bool { return q[i].schedAt.Before(q[j].schedAt) }
+func (q tenantQueue[K, V]) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].schedIndex = i
+	q[j].schedIndex = j
+}
+
+func (q *tenantQueue[K, V]) Push(x any) {
+	tenantStore := x.(*orderedStore[K, V])
+	tenantStore.schedIndex = len(*q)
+	*q = append(*q, tenantStore)
+}
+
+func (q *tenantQueue[K, V]) Pop() any {
+	old := *q
+	n := len(old)
+	tenantStore := old[n-1]
+	old[n-1] = nil
+	tenantStore.schedIndex = -1
+	*q = old[:n-1]
+	return tenantStore
+}
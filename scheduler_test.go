@@ -0,0 +1,77 @@
+package smartqueue
+
+import (
+	"runtime"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/smartqueue/smartqueuetest"
+)
+
+func TestTenantTTLStoreExpiryDrivers(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{name: "Per-tenant cleanup loop", opts: nil},
+		{name: "Shared scheduler", opts: []Option{WithSharedScheduler(2)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var expired atomic.Int32
+			callback := func(event Event[int64, any]) {
+				if event.Reason == Expired {
+					expired.Add(1)
+				}
+			}
+
+			clock := smartqueuetest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+			store := NewTenantStore[int64, any](append(tt.opts, WithClock(clock))...)
+			defer store.Stop()
+
+			const tenants = 50
+			for i := 0; i < tenants; i++ {
+				tenantId := "t" + strconv.Itoa(i)
+				// a long-lived item first, so the short one has to move the wake-up earlier
+				store.Enqueue(tenantId, 1, "long", callback, time.Hour)
+				store.Enqueue(tenantId, 2, "short", callback, 50*time.Millisecond)
+				// updating to a later expiry must outlive the stale heap item
+				store.Enqueue(tenantId, 3, "updated", callback, 50*time.Millisecond)
+				store.Enqueue(tenantId, 3, "updated", callback, time.Hour)
+			}
+
+			// expiry runs on the cleanup goroutines, so wait for it once the time has passed
+			clock.Advance(100 * time.Millisecond)
+			for deadline := time.Now().Add(2 * time.Second); expired.Load() < tenants && time.Now().Before(deadline); {
+				time.Sleep(time.Millisecond)
+			}
+
+			if got := expired.Load(); got != tenants {
+				t.Errorf("%s: expected %d expiries, got %d", tt.name, tenants, got)
+			}
+			// the clock stands still, so nothing else can expire
+			for i := 0; i < tenants; i++ {
+				if n := store.Len("t" + strconv.Itoa(i)); n != 2 {
+					t.Errorf("%s: tenant %d: expected the long and updated keys to survive, got %d items", tt.name, i, n)
+				}
+			}
+		})
+	}
+}
+
+func TestSharedSchedulerGoroutines(t *testing.T) {
+	before := runtime.NumGoroutine()
+	store := NewTenantStore[int64, any](WithSharedScheduler(4))
+	defer store.Stop()
+
+	for i := 0; i < 1000; i++ {
+		store.Enqueue("t"+strconv.Itoa(i), 1, "value", nil, time.Hour)
+	}
+
+	if got := runtime.NumGoroutine() - before; got > 4 {
+		t.Errorf("expected at most 4 scheduler goroutines, got %d", got)
+	}
+}
@@ -0,0 +1,143 @@
+package smartqueue
+
+import (
+	"context"
+	"slices"
+	"strings"
+)
+
+// ShutdownMode decides what Shutdown does with the items still held by the store.
+type ShutdownMode int
+
+const (
+	// ShutdownDrop discards the remaining items without firing their callbacks.
+	ShutdownDrop ShutdownMode = iota + 1
+	// ShutdownFlush fires the callback of every remaining item, dead letters included, with Shutdown.
+	ShutdownFlush
+	// ShutdownDrain hands the remaining items of each tenant to the drain function; dead letters are dropped.
+	ShutdownDrain
+)
+
+func (m ShutdownMode) String() string {
+	switch m {
+	case ShutdownDrop:
+		return "drop"
+	case ShutdownFlush:
+		return "flush"
+	case ShutdownDrain:
+		return "drain"
+	default:
+		return "unknown"
+	}
+}
+
+// DrainFunc receives the items a tenant still held when the store shut down: ready items in
+// dequeue order, followed by leased and delayed items in the order they were enqueued.
+type DrainFunc[K comparable, V any] func(tenantId string, items []Item[K, V])
+
+// Shutdown stops the store, closes the admin servers and empties every tenant according to mode. ShutdownDrain needs a drain
+// function, which is called once per tenant outside of any lock. Waiting callers return ErrClosed,
+// and so does every later operation that reports errors; the others behave as if the tenant was unknown.
+// Shutdown then waits for the background goroutines and the callback dispatcher until ctx is done.
+// It is safe to call more than once: later calls only wait for the first one to finish.
+func (t *tenantTTLStore[K, V]) Shutdown(ctx context.Context, mode ShutdownMode, drain ...DrainFunc[K, V]) error {
+	if mode < ShutdownDrop || mode > ShutdownDrain || (mode == ShutdownDrain && (len(drain) == 0 || drain[0] == nil)) {
+		return ErrInvalidShutdownMode
+	}
+
+	t.tenantsMu.Lock()
+	if t.closed {
+		t.tenantsMu.Unlock()
+		return t.waitStopped(ctx)
+	}
+	t.closed = true
+	tenants := make([]*orderedStore[K, V], 0, len(t.tenantOrderedStore))
+	for _, tenantStore := range t.tenantOrderedStore {
+		tenants = append(tenants, tenantStore)
+	}
+	clear(t.tenantOrderedStore)
+	servers := t.servers
+	t.servers = nil
+	t.tenantsMu.Unlock()
+	close(t.stopCh)
+
+	for _, server := range servers {
+		_ = server.Close()
+	}
+
+	slices.SortFunc(tenants, func(a, b *orderedStore[K, V]) int {
+		return strings.Compare(a.tenantId, b.tenantId)
+	})
+	for _, tenantStore := range tenants {
+		tenantStore.mu.Lock()
+		var items []Item[K, V]
+		switch mode {
+		case ShutdownFlush:
+			t.closeTenantStore(tenantStore, Shutdown)
+		case ShutdownDrain:
+			items = t.remainingItems(tenantStore)
+			t.closeTenantStore(tenantStore)
+		default:
+			t.closeTenantStore(tenantStore)
+		}
+		t.unlock(tenantStore)
+
+		if len(items) != 0 {
+			drain[0](tenantStore.tenantId, items)
+		}
+	}
+
+	go func() {
+		t.wg.Wait()
+		t.cfg.dispatcher.Close()
+		close(t.stopped)
+	}()
+	return t.waitStopped(ctx)
+}
+
+func (t *tenantTTLStore[K, V]) waitStopped(ctx context.Context) error {
+	select {
+	case <-t.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// closing reports whether Shutdown has started.
+func (t *tenantTTLStore[K, V]) closing() bool {
+	select {
+	case <-t.stopCh:
+		return true
+	default:
+		return false
+	}
+}
+
+// remainingItems lists the unexpired items of a tenant for a drain function.
+// Caller must hold tenantSpecificOrderedStore.mu
+func (t *tenantTTLStore[K, V]) remainingItems(tenantSpecificOrderedStore *orderedStore[K, V]) []Item[K, V] {
+	now := t.cfg.clock.Now()
+	items := make([]Item[K, V], 0, len(tenantSpecificOrderedStore.entryMap))
+	for elem := range tenantSpecificOrderedStore.order.All() {
+		e := tenantSpecificOrderedStore.entryMap[elem.Value.(K)]
+		if !e.expired(now) {
+			items = append(items, Item[K, V]{Key: e.id, Value: e.value, ExpiresAt: e.expiryTime})
+		}
+	}
+
+	// leased and delayed items are out of the order list
+	var held []*entry[K, V]
+	for _, e := range tenantSpecificOrderedStore.entryMap {
+		if e.element == nil && !e.expired(now) {
+			held = append(held, e)
+		}
+	}
+	slices.SortFunc(held, func(a, b *entry[K, V]) int {
+		return a.enqueuedAt.Compare(b.enqueuedAt)
+	})
+	for _, e := range held {
+		items = append(items, Item[K, V]{Key: e.id, Value: e.value, ExpiresAt: e.expiryTime})
+	}
+	return items
+}
@@ -0,0 +1,189 @@
+package smartqueue
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTenantTTLStoreShutdown(t *testing.T) {
+	tests := []struct {
+		name         string
+		opts         []Option
+		mode         ShutdownMode
+		wantShutdown int
+		wantDrained  map[string][]int64
+	}{
+		{name: "Drop", mode: ShutdownDrop},
+		{name: "Flush", mode: ShutdownFlush, wantShutdown: 5},
+		{name: "Flush on a worker pool", opts: []Option{WithDispatcher(NewPoolDispatcher(2, 16, BlockWhenFull))}, mode: ShutdownFlush, wantShutdown: 5},
+		{name: "Drain", mode: ShutdownDrain, wantDrained: map[string][]int64{"t0001": {2, 3, 1, 4}, "t0002": {5}}},
+		{name: "Drain with shared scheduler", opts: []Option{WithSharedScheduler(1)}, mode: ShutdownDrain, wantDrained: map[string][]int64{"t0001": {2, 3, 1, 4}, "t0002": {5}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			reasons := map[Reason]int{}
+			callback := func(event Event[int64, any]) {
+				mu.Lock()
+				reasons[event.Reason]++
+				mu.Unlock()
+			}
+
+			store := NewTenantStore[int64, any](tt.opts...)
+			for i := int64(1); i <= 3; i++ {
+				store.Enqueue("t0001", i, i, callback, time.Minute)
+			}
+			// 1 is leased and 4 is delayed, both still belong to the tenant
+			if _, ok := store.Lease("t0001", time.Minute); !ok {
+				t.Fatalf("%s: expected a lease", tt.name)
+			}
+			if _, err := store.EnqueueAfter("t0001", 4, 4, time.Minute, callback, time.Minute); err != nil {
+				t.Fatalf("%s: unexpected error %v", tt.name, err)
+			}
+			store.Enqueue("t0002", 5, 5, callback, time.Minute)
+
+			drained := map[string][]int64{}
+			drain := func(tenantId string, items []Item[int64, any]) {
+				for _, item := range items {
+					drained[tenantId] = append(drained[tenantId], item.Key)
+				}
+			}
+
+			if err := store.Shutdown(context.Background(), tt.mode, drain); err != nil {
+				t.Fatalf("%s: unexpected error %v", tt.name, err)
+			}
+			// a second shutdown, or a Stop, is a no-op
+			if err := store.Shutdown(context.Background(), ShutdownFlush); err != nil {
+				t.Errorf("%s: expected a second shutdown to succeed, got %v", tt.name, err)
+			}
+			store.Stop()
+
+			mu.Lock()
+			if reasons[Shutdown] != tt.wantShutdown || len(reasons) > 1 || (tt.wantShutdown == 0 && len(reasons) != 0) {
+				t.Errorf("%s: expected %d shutdown callbacks and no others, got %v", tt.name, tt.wantShutdown, reasons)
+			}
+			mu.Unlock()
+			if len(drained) != len(tt.wantDrained) {
+				t.Errorf("%s: expected drained %v, got %v", tt.name, tt.wantDrained, drained)
+			}
+			for tenantId, want := range tt.wantDrained {
+				got := drained[tenantId]
+				if len(got) != len(want) {
+					t.Errorf("%s: expected %s to drain %v, got %v", tt.name, tenantId, want, got)
+					continue
+				}
+				for i := range want {
+					if got[i] != want[i] {
+						t.Errorf("%s: expected %s to drain %v, got %v", tt.name, tenantId, want, got)
+						break
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestTenantTTLStoreClosedOperations(t *testing.T) {
+	store := NewTenantStore[int64, any]()
+	store.Enqueue("t0001", 1, 1, nil, time.Minute)
+	lease, _ := store.Lease("t0001", time.Minute)
+	store.Stop()
+
+	if _, err := store.EnqueueContext(context.Background(), "t0001", 2, 2, nil, time.Minute); err != ErrClosed {
+		t.Errorf("expected ErrClosed from EnqueueContext, got %v", err)
+	}
+	if _, err := store.EnqueueAfter("t0001", 2, 2, time.Second, nil, time.Minute); err != ErrClosed {
+		t.Errorf("expected ErrClosed from EnqueueAfter, got %v", err)
+	}
+	if results := store.EnqueueBatch("t0001", []BatchItem[int64, any]{{Key: 2, Value: 2}}); results[0].Err != ErrClosed {
+		t.Errorf("expected ErrClosed from EnqueueBatch, got %v", results[0].Err)
+	}
+	if _, _, err := store.DequeueWait(context.Background(), "t0001"); err != ErrClosed {
+		t.Errorf("expected ErrClosed from DequeueWait, got %v", err)
+	}
+	if _, _, _, err := store.DequeueNext(context.Background()); err != ErrClosed {
+		t.Errorf("expected ErrClosed from DequeueNext, got %v", err)
+	}
+	if err := store.Ack("t0001", lease.Token); err != ErrClosed {
+		t.Errorf("expected ErrClosed from Ack, got %v", err)
+	}
+	if err := store.CreateTenant("t0002"); err != ErrClosed {
+		t.Errorf("expected ErrClosed from CreateTenant, got %v", err)
+	}
+	if err := store.SetTenantConfig("t0002", TenantConfig{Capacity: 1}); err != ErrClosed {
+		t.Errorf("expected ErrClosed from SetTenantConfig, got %v", err)
+	}
+	var snapshot bytes.Buffer
+	if err := store.Snapshot(&snapshot); err != ErrClosed || snapshot.Len() != 0 {
+		t.Errorf("expected ErrClosed from Snapshot and nothing written, got %v, %q", err, snapshot.String())
+	}
+	if _, err := store.Restore(strings.NewReader(`{"version":1}`), DropExpired, nil); err != ErrClosed {
+		t.Errorf("expected ErrClosed from Restore, got %v", err)
+	}
+	if store.Enqueue("t0001", 3, 3, nil, time.Minute) {
+		t.Errorf("expected Enqueue not to report a full tenant")
+	}
+	if _, _, ok := store.Dequeue("t0001"); ok {
+		t.Errorf("expected Dequeue to find nothing")
+	}
+	if tenants := store.Tenants(); len(tenants) != 0 {
+		t.Errorf("expected no tenants, got %v", tenants)
+	}
+}
+
+func TestTenantTTLStoreShutdownWakesWaiters(t *testing.T) {
+	store := NewTenantStore[int64, any](WithTenantConfig("t0001", TenantConfig{Capacity: 1, EvictionPolicy: Block}))
+	store.Enqueue("t0001", 1, 1, nil, time.Minute)
+	// the leased item keeps t0001 full with nothing ready to dequeue
+	store.Lease("t0001", time.Minute)
+
+	errs := make(chan error, 3)
+	go func() {
+		_, _, err := store.DequeueWait(context.Background(), "t0002")
+		errs <- err
+	}()
+	go func() {
+		_, _, _, err := store.DequeueNext(context.Background())
+		errs <- err
+	}()
+	go func() {
+		_, err := store.EnqueueContext(context.Background(), "t0001", 2, 2, nil, time.Minute)
+		errs <- err
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	if err := store.Shutdown(context.Background(), ShutdownDrop); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	for range 3 {
+		select {
+		case err := <-errs:
+			if err != ErrClosed {
+				t.Errorf("expected ErrClosed, got %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("expected shutdown to wake every waiter")
+		}
+	}
+}
+
+func TestTenantTTLStoreShutdownInvalidMode(t *testing.T) {
+	store := NewTenantStore[int64, any]()
+	defer store.Stop()
+
+	if err := store.Shutdown(context.Background(), ShutdownDrain); err != ErrInvalidShutdownMode {
+		t.Errorf("expected ErrInvalidShutdownMode without a drain function, got %v", err)
+	}
+	if err := store.Shutdown(context.Background(), 0); err != ErrInvalidShutdownMode {
+		t.Errorf("expected ErrInvalidShutdownMode for mode 0, got %v", err)
+	}
+	// the store is still open
+	if _, err := store.EnqueueContext(context.Background(), "t0001", 1, 1, nil, time.Minute); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
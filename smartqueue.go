@@ -1,16 +1,87 @@
 package smartqueue
 
 import (
+	"context"
+	"io"
+	"net/http"
 	"time"
 )
 
+// Queue is the type-safe, tenant-aware TTL queue keyed by K and holding values of type V.
+type Queue[K comparable, V any] interface {
+	Enqueue(tenantId string, key K, value V,
+		callback Callback[K, V], ttl time.Duration) (capacityReached bool)
+	EnqueueContext(ctx context.Context, tenantId string, key K, value V,
+		callback Callback[K, V], ttl time.Duration) (capacityReached bool, err error)
+	EnqueueWithPriority(tenantId string, key K, value V, priority Priority,
+		callback Callback[K, V], ttl time.Duration) (capacityReached bool, err error)
+	EnqueueAt(tenantId string, key K, value V, readyAt time.Time,
+		callback Callback[K, V], ttl time.Duration) (capacityReached bool, err error)
+	EnqueueAfter(tenantId string, key K, value V, delay time.Duration,
+		callback Callback[K, V], ttl time.Duration) (capacityReached bool, err error)
+	Pop(tenantID string, key K) (V, bool)
+	Dequeue(tenantID string) (K, V, bool)
+	DequeueWait(ctx context.Context, tenantId string) (K, V, error)
+	DequeueAny(ctx context.Context, tenantIds ...string) (string, K, V, error)
+	DequeueNext(ctx context.Context) (string, K, V, error)
+	Remove(tenantID string, key K)
+	EnqueueBatch(tenantId string, items []BatchItem[K, V]) []EnqueueResult
+	DequeueN(tenantId string, n int) []Item[K, V]
+	RemoveBatch(tenantId string, keys []K) []bool
+	TTL(tenantId string, key K) (time.Duration, bool)
+	Touch(tenantId string, key K) bool
+	ExtendTTL(tenantId string, key K, d time.Duration) bool
+	SetExpiry(tenantId string, key K, at time.Time) bool
+	Persist(tenantId string, key K) bool
+	Lease(tenantId string, visibility time.Duration) (Lease[K, V], bool)
+	Ack(tenantId string, token string) error
+	Nack(tenantId string, token string) error
+	ExtendLease(tenantId string, token string, visibility time.Duration) error
+	DeadLetters(tenantId string) []DeadLetter[K, V]
+	Redrive(tenantId string, keys ...K) int
+	Len(tenantId string) int
+	TotalLen() int
+	Tenants() []string
+	Stats(tenantId string) (TenantStats, bool)
+	GetTenantOrderedMap(tenantId string) (*orderedStore[K, V], bool)
+	SetTenantConfig(tenantId string, tenantConfig TenantConfig) error
+	GetTenantConfig(tenantId string) (TenantConfig, bool)
+	CreateTenant(tenantId string, tenantConfig ...TenantConfig) error
+	DeleteTenant(tenantId string, fireCallbacks bool) bool
+	Shutdown(ctx context.Context, mode ShutdownMode, drain ...DrainFunc[K, V]) error
+	Stop()
+	Snapshot(w io.Writer) error
+	Restore(r io.Reader, policy RestorePolicy, callback Callback[K, V]) (restored int, err error)
+	AdminHandler() http.Handler
+	RegisterHTTPHandlers(port ...int64) (err error)
+}
+
+// SmartQueue is the non-generic API with int64 keys and untyped values,
+// kept for callers written before Queue was introduced.
 type SmartQueue interface {
 	Enqueue(tenantId string, key int64, value any,
 		callback func(tenantId string, key int64), ttl time.Duration) (capacityReached bool)
 	Pop(tenantID string, key int64) (any, bool)
 	Dequeue(tenantID string) (int64, any, bool)
 	Remove(tenantID string, key int64)
-	GetTenantOrderedMap(tenantId string) (*orderedStore, bool)
+	GetTenantOrderedMap(tenantId string) (*orderedStore[int64, any], bool)
 	Stop()
 	RegisterHTTPHandlers(port ...int64) (err error)
 }
+
+// smartQueue adapts a tenantTTLStore[int64, any] to the SmartQueue interface.
+type smartQueue struct {
+	*tenantTTLStore[int64, any]
+}
+
+// Enqueue adapts the original expiry callback with ExpiryCallback.
+func (s smartQueue) Enqueue(tenantId string, key int64, value any,
+	callback func(tenantId string, key int64), ttl time.Duration) (capacityReached bool) {
+
+	return s.tenantTTLStore.Enqueue(tenantId, key, value, ExpiryCallback[int64, any](callback), ttl)
+}
+
+// NewSmartQueue creates a SmartQueue backed by the generic tenant store.
+func NewSmartQueue(opts ...Option) SmartQueue {
+	return smartQueue{newTenantTTLStore[int64, any](opts...)}
+}
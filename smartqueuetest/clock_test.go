@@ -0,0 +1,64 @@
+package smartqueuetest
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		timer     time.Duration
+		advance   time.Duration
+		stop      bool
+		wantFired bool
+	}{
+		{name: "Fires once due", timer: time.Second, advance: time.Second, wantFired: true},
+		{name: "Not due yet", timer: time.Second, advance: 999 * time.Millisecond},
+		{name: "Non-positive fires at once", timer: 0, wantFired: true},
+		{name: "Stopped timer never fires", timer: time.Second, advance: time.Hour, stop: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := NewFakeClock(start)
+			c, stop := clock.NewTimer(tt.timer)
+			if tt.stop && !stop() {
+				t.Fatalf("%s: expected the timer to be pending", tt.name)
+			}
+			clock.Advance(tt.advance)
+
+			if got := clock.Now(); !got.Equal(start.Add(tt.advance)) {
+				t.Errorf("%s: expected %v, got %v", tt.name, start.Add(tt.advance), got)
+			}
+			select {
+			case <-c:
+				if !tt.wantFired {
+					t.Errorf("%s: timer fired early", tt.name)
+				}
+			default:
+				if tt.wantFired {
+					t.Errorf("%s: timer did not fire", tt.name)
+				}
+			}
+		})
+	}
+}
+
+func TestFakeClockBlockUntil(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+
+	done := make(chan struct{})
+	go func() {
+		c, _ := clock.NewTimer(time.Minute)
+		close(done)
+		<-c
+	}()
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	clock.BlockUntil(0)
+	<-done
+}
@@ -0,0 +1,82 @@
+package smartqueuetest
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// RecordedSpan is a span captured by a TraceRecorder.
+type RecordedSpan struct {
+	Name string
+	// Attrs holds the attributes given to Start followed by those given when the span ended.
+	Attrs []slog.Attr
+	Ended bool
+}
+
+// Attr returns the last value of the attribute with the given key.
+func (s RecordedSpan) Attr(key string) (slog.Value, bool) {
+	for i := len(s.Attrs) - 1; i >= 0; i-- {
+		if s.Attrs[i].Key == key {
+			return s.Attrs[i].Value, true
+		}
+	}
+	return slog.Value{}, false
+}
+
+// TraceRecorder is a tracer that satisfies smartqueue.Tracer and keeps every span it starts.
+type TraceRecorder struct {
+	mu    sync.Mutex
+	spans []*RecordedSpan
+}
+
+// NewTraceRecorder returns an empty TraceRecorder.
+func NewTraceRecorder() *TraceRecorder {
+	return &TraceRecorder{}
+}
+
+// Start records a new span; the returned function ends it with extra attributes.
+func (r *TraceRecorder) Start(ctx context.Context, name string, attrs ...slog.Attr) (context.Context, func(attrs ...slog.Attr)) {
+	span := &RecordedSpan{Name: name, Attrs: append([]slog.Attr(nil), attrs...)}
+	r.mu.Lock()
+	r.spans = append(r.spans, span)
+	r.mu.Unlock()
+
+	return ctx, func(attrs ...slog.Attr) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		span.Attrs = append(span.Attrs, attrs...)
+		span.Ended = true
+	}
+}
+
+// Spans returns a copy of the spans started so far, in start order, optionally only those with one of names.
+func (r *TraceRecorder) Spans(names ...string) []RecordedSpan {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	spans := make([]RecordedSpan, 0, len(r.spans))
+	for _, span := range r.spans {
+		if len(names) != 0 && !contains(names, span.Name) {
+			continue
+		}
+		spans = append(spans, RecordedSpan{Name: span.Name, Attrs: append([]slog.Attr(nil), span.Attrs...), Ended: span.Ended})
+	}
+	return spans
+}
+
+// Reset forgets the recorded spans.
+func (r *TraceRecorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = nil
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+package smartqueuetest
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+)
+
+func TestTraceRecorder(t *testing.T) {
+	recorder := NewTraceRecorder()
+
+	_, end := recorder.Start(context.Background(), "first", slog.String("outcome", "pending"))
+	recorder.Start(context.Background(), "second")
+	end(slog.String("outcome", "ok"))
+
+	spans := recorder.Spans()
+	if len(spans) != 2 || spans[0].Name != "first" || spans[1].Name != "second" {
+		t.Fatalf("expected spans first and second, got %+v", spans)
+	}
+	if !spans[0].Ended || spans[1].Ended {
+		t.Errorf("expected only the first span to have ended, got %+v", spans)
+	}
+	if outcome, ok := spans[0].Attr("outcome"); !ok || outcome.String() != "ok" {
+		t.Errorf("expected the end attribute to win, got %v", outcome)
+	}
+	if _, ok := spans[1].Attr("outcome"); ok {
+		t.Errorf("expected no outcome on the second span")
+	}
+	if got := recorder.Spans("second"); len(got) != 1 || got[0].Name != "second" {
+		t.Errorf("expected to filter by name, got %+v", got)
+	}
+
+	recorder.Reset()
+	if got := recorder.Spans(); len(got) != 0 {
+		t.Errorf("expected no spans after Reset, got %+v", got)
+	}
+}
@@ -0,0 +1,331 @@
+package smartqueue
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+)
+
+const snapshotVersion = 1
+
+// RestorePolicy decides what Restore does with items whose expiry time passed while they were on disk.
+type RestorePolicy int
+
+const (
+	// DropExpired discards expired items silently.
+	DropExpired RestorePolicy = iota + 1
+	// FireExpired fires the restore callback of each expired item with Expired.
+	FireExpired
+)
+
+func (p RestorePolicy) String() string {
+	switch p {
+	case DropExpired:
+		return "drop_expired"
+	case FireExpired:
+		return "fire_expired"
+	default:
+		return "unknown"
+	}
+}
+
+// Codec encodes the keys and values written by Snapshot and read by Restore.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+type jsonCodec struct{}
+
+// NewJSONCodec returns a Codec that uses encoding/json. It is the default.
+func NewJSONCodec() Codec {
+	return jsonCodec{}
+}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// A snapshot is a header line followed by one line per tenant, each followed by one line per item.
+type snapshotHeader struct {
+	Version int       `json:"version"`
+	TakenAt time.Time `json:"taken_at"`
+}
+
+type snapshotRecord struct {
+	Tenant string `json:"tenant"`
+	// Config is set on the tenant line when the tenant has its own config
+	Config *TenantConfig `json:"config,omitempty"`
+	Item   *snapshotItem `json:"item,omitempty"`
+}
+
+// snapshotItem holds an item with its absolute times; Key and Value are encoded by the store codec.
+type snapshotItem struct {
+	Key        []byte        `json:"key"`
+	Value      []byte        `json:"value"`
+	Priority   Priority      `json:"priority,omitempty"`
+	EnqueuedAt time.Time     `json:"enqueued_at,omitzero"`
+	ExpiresAt  time.Time     `json:"expires_at,omitzero"`
+	ReadyAt    time.Time     `json:"ready_at,omitzero"`
+	TTL        time.Duration `json:"ttl,omitempty"`
+	Deliveries int           `json:"deliveries,omitempty"`
+	// DeadLetteredAt is set on the items of the dead-letter store
+	DeadLetteredAt time.Time `json:"dead_lettered_at,omitzero"`
+}
+
+// snapshotEntry is an item copied out of a tenant, before encoding or after decoding.
+type snapshotEntry[K comparable, V any] struct {
+	key   K
+	value V
+	item  snapshotItem
+}
+
+// Snapshot writes every tenant, with its items in queue order, their values and their absolute expiry times.
+// Leased items are written as ready items at the front of their tenant, since their leases cannot survive a restart.
+// Callbacks are not written. Each tenant is copied under its own lock, so the snapshot is consistent per tenant.
+// Once the store is closed it returns ErrClosed and writes nothing.
+func (t *tenantTTLStore[K, V]) Snapshot(w io.Writer) error {
+	if t.closing() {
+		return ErrClosed
+	}
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	if err := enc.Encode(snapshotHeader{Version: snapshotVersion, TakenAt: t.cfg.clock.Now()}); err != nil {
+		return err
+	}
+
+	for _, tenantId := range t.Tenants() {
+		tenantConfig, entries, ok := t.snapshotTenant(tenantId)
+		if !ok {
+			continue
+		}
+		if err := enc.Encode(snapshotRecord{Tenant: tenantId, Config: tenantConfig}); err != nil {
+			return err
+		}
+		for _, se := range entries {
+			var err error
+			if se.item.Key, err = t.cfg.codec.Marshal(se.key); err != nil {
+				return fmt.Errorf("smartqueue: encode key of tenant %s: %w", tenantId, err)
+			}
+			if se.item.Value, err = t.cfg.codec.Marshal(se.value); err != nil {
+				return fmt.Errorf("smartqueue: encode value of tenant %s: %w", tenantId, err)
+			}
+			if err := enc.Encode(snapshotRecord{Tenant: tenantId, Item: &se.item}); err != nil {
+				return err
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// snapshotTenant copies the config and items of a tenant, so they can be encoded without holding its lock.
+func (t *tenantTTLStore[K, V]) snapshotTenant(tenantId string) (*TenantConfig, []snapshotEntry[K, V], bool) {
+	t.tenantsMu.RLock()
+	tenantSpecificOrderedStore, ok := t.tenantOrderedStore[tenantId]
+	tenantConfig, configured := t.cfg.tenantConfigs[tenantId]
+	t.tenantsMu.RUnlock()
+	if !ok {
+		return nil, nil, false
+	}
+
+	tenantSpecificOrderedStore.mu.RLock()
+	defer tenantSpecificOrderedStore.mu.RUnlock()
+
+	newEntry := func(e *entry[K, V]) snapshotEntry[K, V] {
+		return snapshotEntry[K, V]{key: e.id, value: e.value, item: snapshotItem{
+			Priority:   e.priority,
+			EnqueuedAt: e.enqueuedAt,
+			ExpiresAt:  e.expiryTime,
+			ReadyAt:    e.readyAt,
+			TTL:        e.ttl,
+			Deliveries: e.deliveries,
+		}}
+	}
+
+	// leased and delayed items are out of the order list
+	var leased, delayed []*entry[K, V]
+	for _, e := range tenantSpecificOrderedStore.entryMap {
+		switch {
+		case e.leased():
+			leased = append(leased, e)
+		case e.delayed():
+			delayed = append(delayed, e)
+		}
+	}
+	slices.SortFunc(leased, func(a, b *entry[K, V]) int { return a.leaseDeadline.Compare(b.leaseDeadline) })
+	slices.SortFunc(delayed, func(a, b *entry[K, V]) int { return a.readyAt.Compare(b.readyAt) })
+
+	entries := make([]snapshotEntry[K, V], 0, len(tenantSpecificOrderedStore.entryMap))
+	for _, e := range leased {
+		entries = append(entries, newEntry(e))
+	}
+	for elem := range tenantSpecificOrderedStore.order.All() {
+		entries = append(entries, newEntry(tenantSpecificOrderedStore.entryMap[elem.Value.(K)]))
+	}
+	for _, e := range delayed {
+		entries = append(entries, newEntry(e))
+	}
+	if deadLetters := tenantSpecificOrderedStore.deadLetters; deadLetters != nil {
+		for elem := range deadLetters.order.All() {
+			e := deadLetters.entryMap[elem.Value.(K)]
+			se := newEntry(e)
+			se.item.DeadLetteredAt = e.lastAccess
+			entries = append(entries, se)
+		}
+	}
+
+	if !configured {
+		return nil, entries, true
+	}
+	return &tenantConfig, entries, true
+}
+
+// Restore reads a snapshot written by Snapshot into the store, giving every item callback.
+// Tenant configs are restored, restored keys replace stored ones, and ready items keep their order
+// behind the items already queued. Items whose expiry time has passed are dropped, or fire callback
+// with Expired under FireExpired. Items that do not fit a full tenant which does not evict are skipped,
+// and Restore then returns ErrCapacityReached once the whole snapshot has been read.
+// It returns the number of items restored, or ErrClosed once the store is closed.
+func (t *tenantTTLStore[K, V]) Restore(r io.Reader, policy RestorePolicy, callback Callback[K, V]) (restored int, err error) {
+	if t.closing() {
+		return 0, ErrClosed
+	}
+	dec := json.NewDecoder(bufio.NewReader(r))
+	var header snapshotHeader
+	if err := dec.Decode(&header); err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+	}
+	if header.Version != snapshotVersion {
+		return 0, fmt.Errorf("%w: unsupported version %d", ErrInvalidSnapshot, header.Version)
+	}
+
+	var skipped bool
+	// the empty tenant ID is valid, so seen tells whether tenantId holds a tenant yet
+	var seen bool
+	var tenantId string
+	var entries []snapshotEntry[K, V]
+	flush := func() error {
+		if !seen {
+			return nil
+		}
+		n, full, err := t.restoreTenant(tenantId, entries, policy, callback)
+		restored += n
+		skipped = skipped || full
+		entries = entries[:0]
+		return err
+	}
+
+	for {
+		var record snapshotRecord
+		if err := dec.Decode(&record); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return restored, fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+		}
+
+		if !seen || record.Tenant != tenantId {
+			if err := flush(); err != nil {
+				return restored, err
+			}
+			tenantId, seen = record.Tenant, true
+		}
+		if record.Config != nil {
+			if err := t.SetTenantConfig(tenantId, *record.Config); err != nil {
+				return restored, err
+			}
+		}
+		if record.Item == nil {
+			// a tenant line; tenants without items come back empty
+			if err := t.CreateTenant(tenantId); err != nil && !errors.Is(err, ErrTenantExists) {
+				return restored, err
+			}
+			continue
+		}
+
+		se := snapshotEntry[K, V]{item: *record.Item}
+		if err := t.cfg.codec.Unmarshal(record.Item.Key, &se.key); err != nil {
+			return restored, fmt.Errorf("%w: decode key of tenant %s: %v", ErrInvalidSnapshot, tenantId, err)
+		}
+		if err := t.cfg.codec.Unmarshal(record.Item.Value, &se.value); err != nil {
+			return restored, fmt.Errorf("%w: decode value of tenant %s: %v", ErrInvalidSnapshot, tenantId, err)
+		}
+		entries = append(entries, se)
+	}
+	if err := flush(); err != nil {
+		return restored, err
+	}
+	if skipped {
+		return restored, ErrCapacityReached
+	}
+	return restored, nil
+}
+
+// restoreTenant puts the decoded items of one tenant under a single lock.
+// It reports whether items were skipped because the tenant was full.
+func (t *tenantTTLStore[K, V]) restoreTenant(tenantId string, entries []snapshotEntry[K, V], policy RestorePolicy,
+	callback Callback[K, V]) (restored int, skipped bool, err error) {
+
+	if len(entries) == 0 {
+		return 0, false, nil
+	}
+	tenantSpecificOrderedStore := t.lockTenantStore(tenantId)
+	if tenantSpecificOrderedStore == nil {
+		return 0, false, ErrClosed
+	}
+	defer t.unlock(tenantSpecificOrderedStore)
+
+	now := t.cfg.clock.Now()
+	for _, se := range entries {
+		item := se.item
+		e := &entry[K, V]{
+			id:         se.key,
+			value:      se.value,
+			expiryTime: item.ExpiresAt,
+			enqueuedAt: item.EnqueuedAt,
+			callback:   callback,
+			lastAccess: item.DeadLetteredAt,
+			deliveries: item.Deliveries,
+			priority:   item.Priority,
+			ttl:        item.TTL,
+		}
+		if !item.DeadLetteredAt.IsZero() {
+			// dead letters never expire
+			t.pushDeadLetter(tenantSpecificOrderedStore, e)
+			restored++
+			continue
+		}
+		if !item.ExpiresAt.IsZero() && !item.ExpiresAt.After(now) {
+			if policy == FireExpired {
+				t.fire(tenantSpecificOrderedStore, e, Expired)
+			}
+			continue
+		}
+
+		if _, ok := tenantSpecificOrderedStore.entryMap[se.key]; !ok &&
+			tenantSpecificOrderedStore.size.Load() >= tenantSpecificOrderedStore.capacity {
+			if policy := tenantSpecificOrderedStore.evictionPolicy; policy == RejectNew || policy == Block ||
+				!t.evict(tenantId, tenantSpecificOrderedStore) {
+				skipped = true
+				continue
+			}
+		}
+
+		t.putLocked(tenantSpecificOrderedStore, se.key, se.value, item.Priority, item.ReadyAt, callback, item.TTL, now)
+		e = tenantSpecificOrderedStore.entryMap[se.key]
+		if !e.delayed() {
+			e.enqueuedAt = item.EnqueuedAt
+		}
+		e.deliveries = item.Deliveries
+		t.setExpiry(tenantSpecificOrderedStore, e, item.ExpiresAt)
+		restored++
+	}
+	return restored, skipped, nil
+}
@@ -0,0 +1,224 @@
+package smartqueue
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/smartqueue/smartqueuetest"
+)
+
+func TestTenantTTLStoreSnapshotRestore(t *testing.T) {
+	clock := smartqueuetest.NewFakeClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	source := NewTenantStore[int64, any](WithClock(clock), WithMaxDeliveries(1))
+	defer source.Stop()
+
+	// with one delivery allowed, a nacked item is dead-lettered
+	source.EnqueueWithPriority("t0001", 6, "dead", 9, nil, time.Hour)
+	lease, _ := source.Lease("t0001", time.Minute)
+	if err := source.Nack("t0001", lease.Token); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	source.Enqueue("t0001", 1, "leased", nil, time.Hour)
+	if _, ok := source.Lease("t0001", time.Minute); !ok {
+		t.Fatal("expected a lease")
+	}
+	source.Enqueue("t0001", 2, "two", nil, time.Hour)
+	source.Enqueue("t0001", 3, "persisted", nil, time.Hour)
+	source.Persist("t0001", 3)
+	source.EnqueueWithPriority("t0001", 4, "urgent", 5, nil, time.Hour)
+	source.EnqueueAfter("t0001", 5, "delayed", time.Minute, nil, time.Hour)
+	source.Enqueue("t0001", 7, "short", nil, time.Second)
+	if err := source.CreateTenant("t0002", TenantConfig{Capacity: 5, DefaultTTL: time.Minute}); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := source.Snapshot(&buf); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	target := NewTenantStore[int64, any](WithClock(clock))
+	defer target.Stop()
+	restored, err := target.Restore(bytes.NewReader(buf.Bytes()), DropExpired, nil)
+	if err != nil || restored != 7 {
+		t.Fatalf("unexpected restore %d, %v", restored, err)
+	}
+
+	if tenantConfig, ok := target.GetTenantConfig("t0002"); !ok || tenantConfig.Capacity != 5 || tenantConfig.DefaultTTL != time.Minute {
+		t.Errorf("unexpected config %+v, %v", tenantConfig, ok)
+	}
+	if ttl, ok := target.TTL("t0001", 3); !ok || ttl != NoExpiry {
+		t.Errorf("expected item 3 persisted, got %v, %v", ttl, ok)
+	}
+	if ttl, ok := target.TTL("t0001", 2); !ok || ttl != time.Hour {
+		t.Errorf("expected the absolute expiry of item 2 kept, got %v, %v", ttl, ok)
+	}
+	deadLetters := target.DeadLetters("t0001")
+	if len(deadLetters) != 1 || deadLetters[0].Key != 6 || deadLetters[0].Deliveries != 1 || !deadLetters[0].DeadLetteredAt.Equal(clock.Now()) {
+		t.Errorf("unexpected dead letters %+v", deadLetters)
+	}
+
+	// the leased item comes back first of its priority, while the delayed item stays hidden
+	var keys []int64
+	for _, item := range target.DequeueN("t0001", 10) {
+		keys = append(keys, item.Key)
+	}
+	if want := []int64{4, 1, 2, 3, 7}; !slices.Equal(keys, want) {
+		t.Errorf("expected %v, got %v", want, keys)
+	}
+	if stats, ok := target.Stats("t0001"); !ok || stats.Delayed != 1 || stats.Len != 1 {
+		t.Errorf("expected the delayed item left, got %+v, %v", stats, ok)
+	}
+}
+
+func TestTenantTTLStoreSnapshotEmptyTenantId(t *testing.T) {
+	source := NewTenantStore[int64, any]()
+	defer source.Stop()
+	source.Enqueue("", 1, "one", nil, time.Hour)
+	source.Enqueue("t0001", 2, "two", nil, time.Hour)
+
+	var buf bytes.Buffer
+	if err := source.Snapshot(&buf); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	target := NewTenantStore[int64, any]()
+	defer target.Stop()
+	restored, err := target.Restore(&buf, DropExpired, nil)
+	if err != nil || restored != 2 {
+		t.Fatalf("unexpected restore %d, %v", restored, err)
+	}
+	if k, v, ok := target.Dequeue(""); !ok || k != 1 || v != "one" {
+		t.Errorf("expected the item of the empty tenant, got %d, %v, %v", k, v, ok)
+	}
+	if n := target.Len("t0001"); n != 1 {
+		t.Errorf("expected 1 item, got %d", n)
+	}
+}
+
+func TestTenantTTLStoreRestoreExpired(t *testing.T) {
+	tests := []struct {
+		name         string
+		policy       RestorePolicy
+		wantRestored int
+		wantReasons  []Reason
+	}{
+		{name: "Dropped", policy: DropExpired, wantRestored: 1},
+		{name: "Fired", policy: FireExpired, wantRestored: 1, wantReasons: []Reason{Expired}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := smartqueuetest.NewFakeClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+			source := NewTenantStore[int64, any](WithClock(clock))
+			defer source.Stop()
+			source.Enqueue("t0001", 1, "short", nil, time.Second)
+			source.Enqueue("t0001", 2, "long", nil, time.Hour)
+
+			var buf bytes.Buffer
+			if err := source.Snapshot(&buf); err != nil {
+				t.Fatalf("%s: unexpected error %v", tt.name, err)
+			}
+
+			// the store was down for longer than the first TTL
+			clock.Advance(time.Minute)
+			target := NewTenantStore[int64, any](WithClock(clock))
+			defer target.Stop()
+			recorder := &eventRecorder{}
+			restored, err := target.Restore(&buf, tt.policy, recorder.callback)
+			if err != nil || restored != tt.wantRestored {
+				t.Fatalf("%s: unexpected restore %d, %v", tt.name, restored, err)
+			}
+			if reasons := recorder.reasons(); !slices.Equal(reasons, tt.wantReasons) {
+				t.Errorf("%s: expected %v, got %v", tt.name, tt.wantReasons, reasons)
+			}
+			if len(recorder.events) == 1 && (recorder.events[0].Key != 1 || recorder.events[0].Value != "short") {
+				t.Errorf("%s: unexpected event %+v", tt.name, recorder.events[0])
+			}
+			if n := target.Len("t0001"); n != 1 {
+				t.Errorf("%s: expected 1 item, got %d", tt.name, n)
+			}
+		})
+	}
+}
+
+type failingCodec struct{}
+
+func (failingCodec) Marshal(v any) ([]byte, error) {
+	return nil, errors.New("not encodable")
+}
+
+func (failingCodec) Unmarshal(data []byte, v any) error {
+	return errors.New("not decodable")
+}
+
+func TestTenantTTLStoreSnapshotErrors(t *testing.T) {
+	store := NewTenantStore[int64, any](WithCodec(failingCodec{}))
+	defer store.Stop()
+	store.Enqueue("t0001", 1, "one", nil, time.Hour)
+
+	if err := store.Snapshot(&bytes.Buffer{}); err == nil {
+		t.Error("expected the codec error")
+	}
+
+	tests := []struct {
+		name     string
+		snapshot string
+	}{
+		{name: "Empty", snapshot: ""},
+		{name: "Unknown version", snapshot: `{"version":2}`},
+		{name: "Truncated", snapshot: "{\"version\":1}\n{\"tenant\":\"t0001\",\"item\":"},
+		{name: "Undecodable value", snapshot: "{\"version\":1}\n{\"tenant\":\"t0001\",\"item\":{\"key\":\"MQ==\",\"value\":\"MQ==\"}}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := store.Restore(strings.NewReader(tt.snapshot), DropExpired, nil); !errors.Is(err, ErrInvalidSnapshot) {
+				t.Errorf("%s: expected ErrInvalidSnapshot, got %v", tt.name, err)
+			}
+		})
+	}
+}
+
+func TestTenantTTLStoreRestoreCapacity(t *testing.T) {
+	source := NewTenantStore[int64, any]()
+	defer source.Stop()
+	for i := int64(1); i <= 3; i++ {
+		source.Enqueue("t0001", i, i, nil, time.Hour)
+	}
+	var buf bytes.Buffer
+	if err := source.Snapshot(&buf); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		policy       EvictionPolicy
+		wantErr      error
+		wantRestored int
+		wantKeys     []int64
+	}{
+		{name: "Reject new", policy: RejectNew, wantErr: ErrCapacityReached, wantRestored: 2, wantKeys: []int64{1, 2}},
+		{name: "Evict oldest", policy: EvictOldest, wantRestored: 3, wantKeys: []int64{2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := NewTenantStore[int64, any](WithCapacity(2), WithEvictionPolicy(tt.policy))
+			defer target.Stop()
+			restored, err := target.Restore(bytes.NewReader(buf.Bytes()), DropExpired, nil)
+			if !errors.Is(err, tt.wantErr) || restored != tt.wantRestored {
+				t.Fatalf("%s: unexpected restore %d, %v", tt.name, restored, err)
+			}
+			var keys []int64
+			for _, item := range target.DequeueN("t0001", 10) {
+				keys = append(keys, item.Key)
+			}
+			if !slices.Equal(keys, tt.wantKeys) {
+				t.Errorf("%s: expected %v, got %v", tt.name, tt.wantKeys, keys)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package smartqueue
+
+import (
+	"slices"
+	"time"
+)
+
+// TenantStats is a point-in-time view of one tenant.
+type TenantStats struct {
+	// Len is the number of stored items, hidden ones included. It counts against Capacity.
+	Len      int
+	Capacity int64
+	// Ready items can be dequeued now.
+	Ready int
+	// Leased items are hidden until acked, nacked or their lease expires.
+	Leased int
+	// Delayed items are hidden until their ready time.
+	Delayed     int
+	DeadLetters int
+	// Waiters counts consumers blocked in DequeueWait or DequeueAny on the tenant.
+	Waiters int
+	// NextExpiry is the earliest TTL or lease deadline, zero when nothing expires.
+	NextExpiry time.Time
+}
+
+// Len returns the number of items stored for the tenant, including leased and delayed ones.
+func (t *tenantTTLStore[K, V]) Len(tenantId string) int {
+	tenantSpecificOrderedStore, ok := t.GetTenantOrderedMap(tenantId)
+	if !ok {
+		return 0
+	}
+	return int(tenantSpecificOrderedStore.size.Load())
+}
+
+// TotalLen returns the number of items stored across all tenants.
+func (t *tenantTTLStore[K, V]) TotalLen() int {
+	t.tenantsMu.RLock()
+	defer t.tenantsMu.RUnlock()
+
+	total := 0
+	for _, tenantSpecificOrderedStore := range t.tenantOrderedStore {
+		total += int(tenantSpecificOrderedStore.size.Load())
+	}
+	return total
+}
+
+// Tenants returns the IDs of the existing tenants in sorted order.
+func (t *tenantTTLStore[K, V]) Tenants() []string {
+	t.tenantsMu.RLock()
+	tenantIds := make([]string, 0, len(t.tenantOrderedStore))
+	for tenantId := range t.tenantOrderedStore {
+		tenantIds = append(tenantIds, tenantId)
+	}
+	t.tenantsMu.RUnlock()
+
+	slices.Sort(tenantIds)
+	return tenantIds
+}
+
+// Stats returns a snapshot of the tenant's counts.
+func (t *tenantTTLStore[K, V]) Stats(tenantId string) (TenantStats, bool) {
+	tenantSpecificOrderedStore, ok := t.GetTenantOrderedMap(tenantId)
+	if !ok {
+		return TenantStats{}, false
+	}
+
+	tenantSpecificOrderedStore.mu.RLock()
+	defer tenantSpecificOrderedStore.mu.RUnlock()
+
+	stats := TenantStats{
+		Len:      int(tenantSpecificOrderedStore.size.Load()),
+		Capacity: tenantSpecificOrderedStore.capacity,
+		Ready:    tenantSpecificOrderedStore.order.Len(),
+		Leased:   len(tenantSpecificOrderedStore.leases),
+		Delayed:  tenantSpecificOrderedStore.readyListHeap.Len(),
+		Waiters:  tenantSpecificOrderedStore.waiters,
+	}
+	if tenantSpecificOrderedStore.deadLetters != nil {
+		stats.DeadLetters = len(tenantSpecificOrderedStore.deadLetters.entryMap)
+	}
+	if tenantSpecificOrderedStore.expiryListHeap.Len() > 0 {
+		stats.NextExpiry = tenantSpecificOrderedStore.expiryListHeap[0].expiration
+	}
+	return stats, true
+}
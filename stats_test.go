@@ -0,0 +1,237 @@
+package smartqueue
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// checkSizeInvariants verifies that every tenant counts each stored item exactly once.
+func checkSizeInvariants(t *testing.T, name string, store *tenantTTLStore[int64, any]) {
+	t.Helper()
+	for _, tenantId := range store.Tenants() {
+		tenantStore, ok := store.GetTenantOrderedMap(tenantId)
+		if !ok {
+			continue
+		}
+		tenantStore.mu.RLock()
+		size := int(tenantStore.size.Load())
+		entries := len(tenantStore.entryMap)
+		placed := tenantStore.order.Len() + len(tenantStore.leases) + tenantStore.readyListHeap.Len()
+		timers := 0
+		for _, e := range tenantStore.entryMap {
+			if e.ttlItem != nil {
+				timers++
+			}
+			if e.leaseItem != nil {
+				timers++
+			}
+		}
+		heapLen := tenantStore.expiryListHeap.Len()
+		indexed, bucketed := tenantStore.access.recent.Len(), 0
+		for b := tenantStore.access.buckets.Front(); b != nil; b = b.Next() {
+			bucketed += b.Value.(*hitBucket).items.Len()
+		}
+		victimErr := checkVictim(store, tenantStore)
+		tenantStore.mu.RUnlock()
+
+		if size != entries {
+			t.Fatalf("%s: tenant %s: size %d != len(entryMap) %d", name, tenantId, size, entries)
+		}
+		if placed != entries {
+			t.Fatalf("%s: tenant %s: ready+leased+delayed %d != len(entryMap) %d", name, tenantId, placed, entries)
+		}
+		if timers != heapLen {
+			t.Fatalf("%s: tenant %s: %d timers but %d heap items", name, tenantId, timers, heapLen)
+		}
+		if indexed != entries || bucketed != entries {
+			t.Fatalf("%s: tenant %s: access order holds %d and %d items, want %d", name, tenantId, indexed, bucketed, entries)
+		}
+		if victimErr != "" {
+			t.Fatalf("%s: tenant %s: %s", name, tenantId, victimErr)
+		}
+	}
+}
+
+// checkVictim compares the indexed eviction victim with a scan of the tenant.
+// Caller must hold tenantStore.mu
+func checkVictim(store *tenantTTLStore[int64, any], tenantStore *orderedStore[int64, any]) string {
+	policy := tenantStore.evictionPolicy
+	var best *entry[int64, any]
+	before := func(a, b *entry[int64, any]) bool {
+		switch {
+		case policy == EvictSoonestExpiry:
+			return !a.expiryTime.IsZero() && (b.expiryTime.IsZero() || a.expiryTime.Before(b.expiryTime))
+		case policy == EvictLFU && a.hits != b.hits:
+			return a.hits < b.hits
+		default:
+			return a.lastAccess.Before(b.lastAccess)
+		}
+	}
+	for _, e := range tenantStore.entryMap {
+		if !e.leased() && (best == nil || before(e, best)) {
+			best = e
+		}
+	}
+	key, ok := store.victim(tenantStore)
+	if ok != (best != nil) {
+		return fmt.Sprintf("victim found %v, want %v", ok, best != nil)
+	}
+	if policy != EvictSoonestExpiry && policy != EvictLRU && policy != EvictLFU {
+		return ""
+	}
+	// ties may pick either item
+	if ok && (before(best, tenantStore.entryMap[key]) || tenantStore.entryMap[key].leased()) {
+		return fmt.Sprintf("victim %d, want %d", key, best.id)
+	}
+	return ""
+}
+
+func TestTenantTTLStoreSizeInvariant(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy EvictionPolicy
+		seed   int64
+	}{
+		{name: "EvictOldest", policy: EvictOldest, seed: 1},
+		{name: "RejectNew", policy: RejectNew, seed: 2},
+		{name: "EvictSoonestExpiry", policy: EvictSoonestExpiry, seed: 3},
+		{name: "EvictLRU", policy: EvictLRU, seed: 4},
+		{name: "EvictLFU", policy: EvictLFU, seed: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewTenantStore[int64, any](
+				WithCapacity(20),
+				WithEvictionPolicy(tt.policy),
+				WithMaxDeliveries(2),
+			).(*tenantTTLStore[int64, any])
+			defer store.Stop()
+
+			r := rand.New(rand.NewSource(tt.seed))
+			var leases []Lease[int64, any]
+			for i := 0; i < 3000; i++ {
+				tenantId := "t" + strconv.Itoa(r.Intn(3))
+				key := int64(r.Intn(40))
+				ttl := time.Duration(1+r.Intn(20)) * time.Millisecond
+
+				switch r.Intn(12) {
+				case 0, 1, 2:
+					store.Enqueue(tenantId, key, "value", nil, ttl)
+				case 3:
+					store.EnqueueAfter(tenantId, key, "value", ttl, nil, time.Minute)
+				case 4:
+					store.EnqueueBatch(tenantId, []BatchItem[int64, any]{{Key: key, TTL: ttl}, {Key: key + 1, TTL: ttl}})
+				case 5:
+					store.Dequeue(tenantId)
+				case 6:
+					store.Remove(tenantId, key)
+				case 7:
+					if lease, ok := store.Lease(tenantId, ttl); ok {
+						leases = append(leases, lease)
+					}
+				case 8:
+					if len(leases) > 0 {
+						lease := leases[r.Intn(len(leases))]
+						if r.Intn(2) == 0 {
+							store.Ack(lease.TenantID, lease.Token)
+						} else {
+							store.Nack(lease.TenantID, lease.Token)
+						}
+					}
+				case 9:
+					store.Persist(tenantId, key)
+				case 10:
+					store.Redrive(tenantId)
+				case 11:
+					store.Pop(tenantId, key)
+				}
+				checkSizeInvariants(t, tt.name, store)
+				if i%500 == 0 {
+					time.Sleep(5 * time.Millisecond)
+				}
+			}
+		})
+	}
+}
+
+func TestTenantTTLStoreLenAndStats(t *testing.T) {
+	store := NewTenantStore[int64, any](WithCapacity(10))
+	defer store.Stop()
+
+	store.Enqueue("t0001", 1, "one", nil, time.Minute)
+	store.Enqueue("t0001", 1, "one-updated", nil, time.Minute)
+	store.Enqueue("t0001", 2, "two", nil, time.Hour)
+	store.EnqueueAfter("t0001", 3, "three", time.Hour, nil, time.Hour)
+	store.Enqueue("t0001", 4, "four", nil, time.Hour)
+	store.Lease("t0001", 30*time.Second)
+	store.Enqueue("t0002", 1, "one", nil, time.Minute)
+	store.Dequeue("t0002")
+	store.CreateTenant("t0003")
+	store.Enqueue("t0003", 1, "one", nil, time.Minute)
+	store.Remove("t0003", 1)
+
+	tests := []struct {
+		name      string
+		tenantId  string
+		wantLen   int
+		wantStats TenantStats
+	}{
+		{
+			name:     "Updated, leased and delayed items count once",
+			tenantId: "t0001",
+			wantLen:  4,
+			wantStats: TenantStats{
+				Len: 4, Capacity: 10, Ready: 2, Leased: 1, Delayed: 1,
+			},
+		},
+		{
+			name:      "Dequeue frees the slot",
+			tenantId:  "t0002",
+			wantStats: TenantStats{Capacity: 10},
+		},
+		{
+			name:      "Remove frees the slot",
+			tenantId:  "t0003",
+			wantStats: TenantStats{Capacity: 10},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := store.Len(tt.tenantId); got != tt.wantLen {
+				t.Errorf("%s: expected Len %d, got %d", tt.name, tt.wantLen, got)
+			}
+			stats, ok := store.Stats(tt.tenantId)
+			if !ok {
+				t.Fatalf("%s: expected stats", tt.name)
+			}
+			stats.NextExpiry = time.Time{}
+			if stats != tt.wantStats {
+				t.Errorf("%s: expected stats %+v, got %+v", tt.name, tt.wantStats, stats)
+			}
+		})
+	}
+
+	if got := store.TotalLen(); got != 4 {
+		t.Errorf("expected TotalLen 4, got %d", got)
+	}
+	if got := store.Tenants(); !slices.Equal(got, []string{"t0001", "t0002", "t0003"}) {
+		t.Errorf("unexpected tenants %v", got)
+	}
+	if _, ok := store.Stats("unknown"); ok {
+		t.Errorf("expected no stats for an unknown tenant")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	store.DequeueWait(ctx, "t0002")
+	if stats, _ := store.Stats("t0002"); stats.Waiters != 0 {
+		t.Errorf("expected waiters released, got %d", stats.Waiters)
+	}
+}
@@ -0,0 +1,101 @@
+package smartqueue
+
+import "time"
+
+// TenantConfig holds the limits applied to a single tenant.
+// Zero fields fall back to the store-wide settings.
+type TenantConfig struct {
+	// Capacity is the maximum number of items the tenant may hold.
+	Capacity int64
+	// DefaultTTL is used when an item is enqueued with a non-positive ttl.
+	DefaultTTL time.Duration
+	// MaxTTL caps the ttl of every item. Zero means no cap.
+	MaxTTL time.Duration
+	// EvictionPolicy decides what happens when the tenant is full.
+	EvictionPolicy EvictionPolicy
+	// Weight is the share of DequeueNext the tenant gets relative to the others. Zero means 1.
+	Weight int64
+}
+
+func (c TenantConfig) validate() error {
+	if c.Capacity < 0 || c.DefaultTTL < 0 || c.MaxTTL < 0 || c.Weight < 0 {
+		return ErrInvalidTenantConfig
+	}
+	return nil
+}
+
+// resolveTenantConfig fills the zero fields of a tenant config from the store-wide settings.
+func (t *tenantTTLStore[K, V]) resolveTenantConfig(tenantConfig TenantConfig) TenantConfig {
+	if tenantConfig.Capacity == 0 {
+		tenantConfig.Capacity = t.cfg.capacity
+	}
+	if tenantConfig.DefaultTTL == 0 {
+		tenantConfig.DefaultTTL = t.cfg.defaultTTL
+	}
+	if tenantConfig.EvictionPolicy == 0 {
+		tenantConfig.EvictionPolicy = t.cfg.evictionPolicy
+	}
+	if tenantConfig.Weight == 0 {
+		tenantConfig.Weight = 1
+	}
+	return tenantConfig
+}
+
+// SetTenantConfig registers a tenant with the given limits, or updates the limits of an existing tenant.
+// When the capacity drops below the number of stored items, items are evicted by the tenant policy.
+func (t *tenantTTLStore[K, V]) SetTenantConfig(tenantId string, tenantConfig TenantConfig) error {
+	if err := tenantConfig.validate(); err != nil {
+		return err
+	}
+
+	t.tenantsMu.Lock()
+	if t.closed {
+		t.tenantsMu.Unlock()
+		return ErrClosed
+	}
+	t.cfg.tenantConfigs[tenantId] = tenantConfig
+	t.tenantsMu.Unlock()
+
+	resolved := t.resolveTenantConfig(tenantConfig)
+	tenantSpecificOrderedStore := t.lockTenantStore(tenantId)
+	if tenantSpecificOrderedStore == nil {
+		return ErrClosed
+	}
+	defer t.unlock(tenantSpecificOrderedStore)
+
+	tenantSpecificOrderedStore.capacity = resolved.Capacity
+	tenantSpecificOrderedStore.defaultTTL = resolved.DefaultTTL
+	tenantSpecificOrderedStore.maxTTL = resolved.MaxTTL
+	tenantSpecificOrderedStore.evictionPolicy = resolved.EvictionPolicy
+	t.fair.mu.Lock()
+	tenantSpecificOrderedStore.weight = resolved.Weight
+	t.fair.mu.Unlock()
+
+	for int64(len(tenantSpecificOrderedStore.entryMap)) > tenantSpecificOrderedStore.capacity {
+		if !t.evict(tenantId, tenantSpecificOrderedStore) {
+			break
+		}
+	}
+	// a larger capacity may unblock waiting enqueues
+	tenantSpecificOrderedStore.spaceFreed.notify()
+	return nil
+}
+
+// GetTenantConfig returns the effective limits of an existing tenant.
+func (t *tenantTTLStore[K, V]) GetTenantConfig(tenantId string) (TenantConfig, bool) {
+	tenantSpecificOrderedStore, ok := t.GetTenantOrderedMap(tenantId)
+	if !ok {
+		return TenantConfig{}, false
+	}
+
+	tenantSpecificOrderedStore.mu.RLock()
+	defer tenantSpecificOrderedStore.mu.RUnlock()
+
+	return TenantConfig{
+		Capacity:       tenantSpecificOrderedStore.capacity,
+		DefaultTTL:     tenantSpecificOrderedStore.defaultTTL,
+		MaxTTL:         tenantSpecificOrderedStore.maxTTL,
+		EvictionPolicy: tenantSpecificOrderedStore.evictionPolicy,
+		Weight:         tenantSpecificOrderedStore.weight,
+	}, true
+}
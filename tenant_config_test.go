@@ -0,0 +1,112 @@
+package smartqueue
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/smartqueue/smartqueuetest"
+)
+
+func TestTenantTTLStoreSetTenantConfig(t *testing.T) {
+	var callbackTriggered int32
+	mockCallback := ExpiryCallback[int64, any](func(tenantId string, key int64) {
+		atomic.AddInt32(&callbackTriggered, 1)
+	})
+
+	tests := []struct {
+		name          string
+		initial       int
+		tenantConfig  TenantConfig
+		wantErr       error
+		wantLen       int
+		wantFront     int64
+		wantCallbacks int32
+	}{
+		{
+			name:         "Invalid config is rejected",
+			initial:      3,
+			tenantConfig: TenantConfig{Capacity: -1},
+			wantErr:      ErrInvalidTenantConfig,
+			wantLen:      3,
+			wantFront:    1,
+		},
+		{
+			name:         "Growing capacity keeps all items",
+			initial:      3,
+			tenantConfig: TenantConfig{Capacity: 100},
+			wantLen:      3,
+			wantFront:    1,
+		},
+		{
+			name:          "Shrinking capacity evicts the oldest items",
+			initial:       5,
+			tenantConfig:  TenantConfig{Capacity: 2},
+			wantLen:       2,
+			wantFront:     4,
+			wantCallbacks: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			atomic.StoreInt32(&callbackTriggered, 0)
+			store := NewTenantStore[int64, any](WithCapacity(10)).(*tenantTTLStore[int64, any])
+			defer store.Stop()
+
+			for i := 1; i <= tt.initial; i++ {
+				store.Enqueue("t0001", int64(i), i, mockCallback, 5*time.Second)
+			}
+
+			err := store.SetTenantConfig("t0001", tt.tenantConfig)
+			if err != tt.wantErr {
+				t.Fatalf("%s: expected err=%v, got %v", tt.name, tt.wantErr, err)
+			}
+
+			tenantStore, _ := store.GetTenantOrderedMap("t0001")
+			tenantStore.mu.RLock()
+			gotLen := len(tenantStore.entryMap)
+			gotFront := tenantStore.order.Front().Value.(int64)
+			tenantStore.mu.RUnlock()
+
+			if gotLen != tt.wantLen {
+				t.Errorf("%s: expected len=%d, got %d", tt.name, tt.wantLen, gotLen)
+			}
+			if gotFront != tt.wantFront {
+				t.Errorf("%s: expected front=%d, got %d", tt.name, tt.wantFront, gotFront)
+			}
+			if got := atomic.LoadInt32(&callbackTriggered); got != tt.wantCallbacks {
+				t.Errorf("%s: expected %d callbacks, got %d", tt.name, tt.wantCallbacks, got)
+			}
+		})
+	}
+}
+
+func TestTenantTTLStoreTenantConfigTTL(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewTenantStore[int64, any](
+		WithClock(smartqueuetest.NewFakeClock(now)),
+		WithTenantConfig("premium", TenantConfig{DefaultTTL: time.Hour, MaxTTL: 2 * time.Hour}),
+	).(*tenantTTLStore[int64, any])
+	defer store.Stop()
+
+	mockCallback := ExpiryCallback[int64, any](func(tenantId string, key int64) {})
+	store.Enqueue("premium", 1, "default", mockCallback, 0)
+	store.Enqueue("premium", 2, "capped", mockCallback, 24*time.Hour)
+
+	tenantConfig, ok := store.GetTenantConfig("premium")
+	if !ok || tenantConfig.Capacity != defaultCapacity || tenantConfig.EvictionPolicy != EvictOldest {
+		t.Errorf("expected store defaults to fill the tenant config, got %+v", tenantConfig)
+	}
+
+	tenantStore, _ := store.GetTenantOrderedMap("premium")
+	tenantStore.mu.RLock()
+	defer tenantStore.mu.RUnlock()
+
+	if want := now.Add(time.Hour); !tenantStore.entryMap[1].expiryTime.Equal(want) {
+		t.Errorf("expected default TTL expiry %v, got %v", want, tenantStore.entryMap[1].expiryTime)
+	}
+	if want := now.Add(2 * time.Hour); !tenantStore.entryMap[2].expiryTime.Equal(want) {
+		t.Errorf("expected capped expiry %v, got %v", want, tenantStore.entryMap[2].expiryTime)
+	}
+}
@@ -2,47 +2,62 @@ package smartqueue
 
 import (
 	"container/heap"
-	"encoding/json"
-	"fmt"
+	"context"
+	"log/slog"
 	"net/http"
-	"strconv"
-	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
-const (
-	defaultPort       = 8098
-	tenantSpecificUrl = `/smartqueue/tenant/`
-	entryParam        = `entry`
-)
-
-type tenantView struct {
-	Key        int64         `json:"key"`
-	Value      any           `json:"value"`
-	ExpiryTime int64         `json:"expiry_time"`
-	TTL        time.Duration `json:"ttl_remaining"`
-}
-
-type tenantTTLStore struct {
+type tenantTTLStore[K comparable, V any] struct {
 	tenantsMu          sync.RWMutex
-	tenantOrderedStore map[string]*orderedStore
+	tenantOrderedStore map[string]*orderedStore[K, V]
 	stopCh             chan struct{}
+	stopped            chan struct{}
+	closed             bool           // guarded by tenantsMu
+	servers            []*http.Server // guarded by tenantsMu
 	wg                 sync.WaitGroup
-	capacity           int64
+	cfg                config
+	scheduler          *expiryScheduler[K, V]
+	fair               *fairQueue[K, V]
+	leaseSeq           atomic.Uint64
+	metrics            *metrics
+}
+
+// NewTenantStore creates a tenant-aware TTL queue with keys of type K and values of type V.
+// Existing callers that only set a capacity can pass WithCapacity.
+func NewTenantStore[K comparable, V any](opts ...Option) Queue[K, V] {
+	return newTenantTTLStore[K, V](opts...)
 }
 
-func NewTenantStore(capacity int64) SmartQueue {
-	t := &tenantTTLStore{
-		tenantOrderedStore: make(map[string]*orderedStore),
+func newTenantTTLStore[K comparable, V any](opts ...Option) *tenantTTLStore[K, V] {
+	t := &tenantTTLStore[K, V]{
+		tenantOrderedStore: make(map[string]*orderedStore[K, V]),
 		stopCh:             make(chan struct{}),
-		capacity:           capacity,
+		stopped:            make(chan struct{}),
+		cfg:                newConfig(opts),
+		metrics:            newMetrics(),
+	}
+	t.fair = newFairQueue[K, V](t.cfg.fairnessPolicy)
+
+	if t.cfg.idleTenantTimeout > 0 {
+		t.wg.Add(1)
+		go t.reapIdleTenantsLoop()
+	}
+
+	if t.cfg.schedulerShards > 0 {
+		t.scheduler = newExpiryScheduler[K, V](t.cfg.schedulerShards)
+		for _, shard := range t.scheduler.shards {
+			t.wg.Add(1)
+			go t.runSchedulerShard(shard)
+		}
 	}
 
 	return t
 }
 
-func (t *tenantTTLStore) GetTenantOrderedMap(tenantId string) (*orderedStore, bool) {
+func (t *tenantTTLStore[K, V]) GetTenantOrderedMap(tenantId string) (*orderedStore[K, V], bool) {
 	t.tenantsMu.RLock()
 	defer t.tenantsMu.RUnlock()
 
@@ -53,108 +68,272 @@ func (t *tenantTTLStore) GetTenantOrderedMap(tenantId string) (*orderedStore, bo
 	return td, true
 }
 
-// Enqueue Insert or update key for a tenant with per-entry TTL
-func (t *tenantTTLStore) Enqueue(tenantId string, key int64, value any,
-	callback func(tenantId string, key int64), ttl time.Duration) (capacityReached bool) {
+// Enqueue Insert or update key for a tenant with per-entry TTL.
+// Updating an existing key fires its previous callback with Replaced.
+// A rejected item is reported through capacityReached only; use EnqueueContext to get the error.
+func (t *tenantTTLStore[K, V]) Enqueue(tenantId string, key K, value V,
+	callback Callback[K, V], ttl time.Duration) (capacityReached bool) {
 
-	tenantSpecificOrderedStore := t.tenantStore(tenantId)
+	capacityReached, _ = t.EnqueueContext(context.Background(), tenantId, key, value, callback, ttl)
+	return capacityReached
+}
 
-	tenantSpecificOrderedStore.mu.Lock()
-	defer tenantSpecificOrderedStore.mu.Unlock()
-	if tenantSpecificOrderedStore.size.Load() >= tenantSpecificOrderedStore.capacity {
+// EnqueueContext inserts or updates key like Enqueue. When the tenant is full it applies the
+// tenant eviction policy: RejectNew returns ErrCapacityReached and Block waits for space until ctx is done.
+func (t *tenantTTLStore[K, V]) EnqueueContext(ctx context.Context, tenantId string, key K, value V,
+	callback Callback[K, V], ttl time.Duration) (capacityReached bool, err error) {
+
+	return t.enqueue(ctx, tenantId, key, value, DefaultPriority, time.Time{}, callback, ttl)
+}
+
+// EnqueueWithPriority inserts or updates key like EnqueueContext, at the given priority level.
+// Updating a key to a different priority moves it to the back of its new level.
+func (t *tenantTTLStore[K, V]) EnqueueWithPriority(tenantId string, key K, value V, priority Priority,
+	callback Callback[K, V], ttl time.Duration) (capacityReached bool, err error) {
+
+	return t.enqueue(context.Background(), tenantId, key, value, priority, time.Time{}, callback, ttl)
+}
+
+// enqueue inserts or updates key; a readyAt in the future holds the item back until then.
+func (t *tenantTTLStore[K, V]) enqueue(ctx context.Context, tenantId string, key K, value V, priority Priority,
+	readyAt time.Time, callback Callback[K, V], ttl time.Duration) (capacityReached bool, err error) {
+
+	if tracer := t.cfg.tracer; tracer != nil {
+		var end func(attrs ...slog.Attr)
+		ctx, end = tracer.Start(ctx, SpanEnqueue, slog.String(AttrTenant, tenantId), slog.Any(AttrKey, key))
+		defer func() { end(errorOutcome(err)) }()
+	}
+
+	tenantSpecificOrderedStore := t.lockTenantStore(tenantId)
+	if tenantSpecificOrderedStore == nil {
+		return capacityReached, ErrClosed
+	}
+	for {
+		if _, ok := tenantSpecificOrderedStore.entryMap[key]; ok ||
+			tenantSpecificOrderedStore.size.Load() < tenantSpecificOrderedStore.capacity {
+			break
+		}
 		capacityReached = true
-		// dequeue the item and then enqueue
-		t.removeOldestForCapacity(tenantId, tenantSpecificOrderedStore)
-	} else {
-		tenantSpecificOrderedStore.size.Add(1)
+
+		if tenantSpecificOrderedStore.evictionPolicy == RejectNew {
+			t.unlock(tenantSpecificOrderedStore)
+			return capacityReached, ErrCapacityReached
+		}
+		if tenantSpecificOrderedStore.evictionPolicy != Block {
+			// dequeue the item and then enqueue
+			if !t.evict(tenantId, tenantSpecificOrderedStore) {
+				// every stored item is leased
+				t.unlock(tenantSpecificOrderedStore)
+				return capacityReached, ErrCapacityReached
+			}
+			break
+		}
+
+		freed := tenantSpecificOrderedStore.spaceFreed.wait()
+		t.unlock(tenantSpecificOrderedStore)
+		select {
+		case <-ctx.Done():
+			return capacityReached, ctx.Err()
+		case <-t.stopCh:
+			return capacityReached, ErrClosed
+		case <-freed:
+		}
+		tenantSpecificOrderedStore = t.lockTenantStore(tenantId)
+		if tenantSpecificOrderedStore == nil {
+			return capacityReached, ErrClosed
+		}
+	}
+	defer t.unlock(tenantSpecificOrderedStore)
+
+	exp := t.putLocked(tenantSpecificOrderedStore, key, value, priority, readyAt, callback, ttl, t.cfg.clock.Now())
+	t.setExpiry(tenantSpecificOrderedStore, tenantSpecificOrderedStore.entryMap[key], exp)
+	t.metrics.enqueued.Add(1)
+
+	return capacityReached, nil
+}
+
+// putLocked inserts or updates key and returns its expiry time; the caller updates the expiry heap.
+// A delayed item counts as enqueued when it becomes ready, and its TTL starts from then.
+// Caller must hold tenantSpecificOrderedStore.mu
+func (t *tenantTTLStore[K, V]) putLocked(tenantSpecificOrderedStore *orderedStore[K, V], key K, value V, priority Priority,
+	readyAt time.Time, callback Callback[K, V], ttl time.Duration, now time.Time) time.Time {
+
+	start := now
+	if readyAt.After(now) {
+		start = readyAt
 	}
-	exp := time.Now().Add(ttl)
+	ttl = tenantSpecificOrderedStore.ttlFor(ttl)
+	exp := start.Add(ttl)
+	tenantSpecificOrderedStore.lastActive = now
 
 	e, ok := tenantSpecificOrderedStore.entryMap[key]
 	if ok {
+		t.fire(tenantSpecificOrderedStore, e, Replaced)
 		e.value = value
 		e.expiryTime = exp
+		e.ttl = ttl
+		e.enqueuedAt = start
+		e.callback = callback
+		tenantSpecificOrderedStore.touchAccess(e, now)
 	} else {
-		elem := tenantSpecificOrderedStore.order.PushBack(key)
-		tenantSpecificOrderedStore.entryMap[key] = &entry{
+		e = &entry[K, V]{
 			id:         key,
 			value:      value,
 			expiryTime: exp,
-			element:    elem,
-			expiryFunc: callback,
+			ttl:        ttl,
+			enqueuedAt: start,
+			callback:   callback,
+			lastAccess: now,
 		}
+		tenantSpecificOrderedStore.entryMap[key] = e
+		tenantSpecificOrderedStore.access.add(e)
+		tenantSpecificOrderedStore.size.Add(1)
+	}
+	if e.leased() {
+		// the update is seen once the lease ends
+		e.priority = priority
+		return exp
 	}
+	t.place(tenantSpecificOrderedStore, e, priority, readyAt, now)
+	return exp
+}
 
-	heap.Push(&tenantSpecificOrderedStore.expiryListHeap, expiry{
-		tenantId:   tenantId,
-		key:        key,
-		expiration: exp,
-	})
+// place puts e at the back of its priority level, or holds it back until readyAt.
+// An item already queued at the same priority keeps its position.
+// Caller must hold tenantSpecificOrderedStore.mu
+func (t *tenantTTLStore[K, V]) place(tenantSpecificOrderedStore *orderedStore[K, V], e *entry[K, V], priority Priority,
+	readyAt time.Time, now time.Time) {
 
-	return capacityReached
+	ready := !readyAt.After(now)
+	if e.element != nil && (e.priority != priority || !ready) {
+		tenantSpecificOrderedStore.order.Remove(e.element, e.priority)
+		e.element = nil
+	}
+	e.priority = priority
+
+	if !ready {
+		e.readyAt = readyAt
+		e.readyItem = tenantSpecificOrderedStore.readyListHeap.set(e.readyItem, tenantSpecificOrderedStore.tenantId, e.id, readyAt)
+		if tenantSpecificOrderedStore.readyListHeap.top(e.readyItem) {
+			t.expiryScheduled(tenantSpecificOrderedStore, readyAt)
+		}
+		return
+	}
+
+	e.readyAt = time.Time{}
+	tenantSpecificOrderedStore.readyListHeap.remove(e.readyItem)
+	e.readyItem = nil
+	if e.element == nil {
+		e.element, e.seq = tenantSpecificOrderedStore.order.PushBack(e.id, priority)
+		tenantSpecificOrderedStore.itemAdded.notify()
+		t.fair.add(tenantSpecificOrderedStore)
+	}
 }
 
-func (t *tenantTTLStore) Pop(tenantID string, key int64) (any, bool) {
+func (t *tenantTTLStore[K, V]) Pop(tenantID string, key K) (value V, exists bool) {
+	if tracer := t.cfg.tracer; tracer != nil {
+		_, end := tracer.Start(context.Background(), SpanPop, slog.String(AttrTenant, tenantID), slog.Any(AttrKey, key))
+		defer func() { end(hitOutcome(exists)) }()
+	}
 
-	tenantSpecificOrderedStore, ok := t.tenantOrderedStore[tenantID]
+	tenantSpecificOrderedStore, ok := t.GetTenantOrderedMap(tenantID)
 	if !ok {
-		return nil, false
+		return value, false
 	}
 
 	tenantSpecificOrderedStore.mu.Lock()
-	defer tenantSpecificOrderedStore.mu.Unlock()
+	defer t.unlock(tenantSpecificOrderedStore)
 
 	e, ok := tenantSpecificOrderedStore.entryMap[key]
-	if !ok {
-		return nil, false
+	if !ok || e.leased() || e.delayed() {
+		t.metrics.popMisses.Add(1)
+		return value, false
 	}
 
-	if time.Now().After(e.expiryTime) {
-		t.removeInternal(tenantID, key, true)
-		return nil, false
+	now := t.cfg.clock.Now()
+	if e.expired(now) {
+		t.removeInternal(tenantSpecificOrderedStore, key, Expired)
+		t.metrics.popMisses.Add(1)
+		return value, false
 	}
 
+	tenantSpecificOrderedStore.touchAccess(e, now)
+	tenantSpecificOrderedStore.lastActive = now
+
+	t.metrics.popHits.Add(1)
 	return e.value, true
 }
 
-func (t *tenantTTLStore) Dequeue(tenantId string) (int64, any, bool) {
-	tenantSpecificOrderedStore, ok := t.tenantOrderedStore[tenantId]
+func (t *tenantTTLStore[K, V]) Dequeue(tenantId string) (key K, value V, exists bool) {
+	if tracer := t.cfg.tracer; tracer != nil {
+		_, end := tracer.Start(context.Background(), SpanDequeue, slog.String(AttrTenant, tenantId))
+		defer func() {
+			if exists {
+				end(hitOutcome(exists), slog.Any(AttrKey, key))
+			} else {
+				end(hitOutcome(exists))
+			}
+		}()
+	}
+
+	tenantSpecificOrderedStore, ok := t.GetTenantOrderedMap(tenantId)
 	if !ok {
-		return 0, nil, false
+		return key, value, false
 	}
 
 	tenantSpecificOrderedStore.mu.Lock()
-	defer tenantSpecificOrderedStore.mu.Unlock()
+	defer t.unlock(tenantSpecificOrderedStore)
+
+	e, ok := t.dequeueLocked(tenantSpecificOrderedStore)
+	if !ok {
+		return key, value, false
+	}
+	return e.id, e.value, true
+}
 
-	front := tenantSpecificOrderedStore.order.Front()
+// dequeueLocked removes the next item, taking the highest non-empty priority first.
+// An expired item is dropped and reported as missing.
+// Caller must hold tenantSpecificOrderedStore.mu
+func (t *tenantTTLStore[K, V]) dequeueLocked(tenantSpecificOrderedStore *orderedStore[K, V]) (e *entry[K, V], exists bool) {
+	now := t.cfg.clock.Now()
+	front := t.next(tenantSpecificOrderedStore, now)
 	if front == nil {
-		return 0, nil, false
+		return nil, false
 	}
 
-	key := front.Value.(int64)
-	e := tenantSpecificOrderedStore.entryMap[key]
+	frontKey := front.Value.(K)
+	e = tenantSpecificOrderedStore.entryMap[frontKey]
 
-	if time.Now().After(e.expiryTime) {
-		t.removeInternal(tenantId, key, true)
-		return 0, nil, false
+	if e.expired(now) {
+		t.removeInternal(tenantSpecificOrderedStore, frontKey, Expired)
+		return nil, false
 	}
 
-	t.removeInternal(tenantId, key)
-	return key, e.value, true
+	tenantSpecificOrderedStore.lastActive = now
+	t.removeInternal(tenantSpecificOrderedStore, frontKey)
+	t.metrics.dequeued.Add(1)
+	return e, true
 }
 
-func (t *tenantTTLStore) Remove(tenantID string, key int64) {
-	tenantSpecificOrderedStore, ok := t.tenantOrderedStore[tenantID]
+func (t *tenantTTLStore[K, V]) Remove(tenantID string, key K) {
+	found := false
+	if tracer := t.cfg.tracer; tracer != nil {
+		_, end := tracer.Start(context.Background(), SpanRemove, slog.String(AttrTenant, tenantID), slog.Any(AttrKey, key))
+		defer func() { end(hitOutcome(found)) }()
+	}
+
+	tenantSpecificOrderedStore, ok := t.GetTenantOrderedMap(tenantID)
 	if !ok {
 		return
 	}
 
 	tenantSpecificOrderedStore.mu.Lock()
-	defer tenantSpecificOrderedStore.mu.Unlock()
-	t.removeInternal(tenantID, key)
+	defer t.unlock(tenantSpecificOrderedStore)
+	_, found = tenantSpecificOrderedStore.entryMap[key]
+	t.removeInternal(tenantSpecificOrderedStore, key, Removed)
 }
 
-func (t *tenantTTLStore) tenantStore(tenantId string) *orderedStore {
+func (t *tenantTTLStore[K, V]) tenantStore(tenantId string) *orderedStore[K, V] {
 	t.tenantsMu.RLock()
 	tenantSpecificOrderedStore, ok := t.tenantOrderedStore[tenantId]
 	t.tenantsMu.RUnlock()
@@ -163,205 +342,183 @@ func (t *tenantTTLStore) tenantStore(tenantId string) *orderedStore {
 		t.tenantsMu.Lock()
 		// double-check in case another goroutine created it
 		tenantSpecificOrderedStore, ok = t.tenantOrderedStore[tenantId]
-		if !ok {
-			tenantSpecificOrderedStore = newOrderedStore(t.capacity)
-			t.tenantOrderedStore[tenantId] = tenantSpecificOrderedStore
-
-			t.wg.Add(1)
-			go t.cleanupTenantLoop(tenantId, tenantSpecificOrderedStore)
+		if !ok && !t.closed {
+			tenantSpecificOrderedStore = t.addTenantLocked(tenantId)
 		}
 		t.tenantsMu.Unlock()
 	}
 	return tenantSpecificOrderedStore
 }
 
-func (t *tenantTTLStore) removeInternal(tenantID string, key int64, limitReached ...bool) {
-	// Caller must hold tenantSpecificOrderedStore.mu
-	tenantSpecificOrderedStore, ok := t.tenantOrderedStore[tenantID]
-	if !ok {
-		return
-	}
-	e, ok := tenantSpecificOrderedStore.entryMap[key]
-	if ok {
-		if limitReached != nil && limitReached[0] {
-			e.expiryFunc(tenantID, key)
-		}
-		tenantSpecificOrderedStore.order.Remove(e.element)
-		delete(tenantSpecificOrderedStore.entryMap, key)
-	} else {
-		//fmt.Println("already delete: ", key)
+// addTenantLocked creates the store of a new tenant. Caller must hold t.tenantsMu
+func (t *tenantTTLStore[K, V]) addTenantLocked(tenantId string) *orderedStore[K, V] {
+	tenantSpecificOrderedStore := newOrderedStore[K, V](tenantId, t.resolveTenantConfig(t.cfg.tenantConfigs[tenantId]))
+	tenantSpecificOrderedStore.lastActive = t.cfg.clock.Now()
+	t.tenantOrderedStore[tenantId] = tenantSpecificOrderedStore
 
+	if t.scheduler == nil {
+		t.wg.Add(1)
+		go t.cleanupTenantLoop(tenantId, tenantSpecificOrderedStore)
 	}
-
+	return tenantSpecificOrderedStore
 }
 
-func (t *tenantTTLStore) cleanupTenantLoop(tenantID string, tenantStore *orderedStore) {
-	defer t.wg.Done()
-
+// lockTenantStore returns the locked store of tenantId, creating the tenant when needed.
+// It retries when the store it found was deleted before the lock was taken, and returns nil once the store is shut down.
+func (t *tenantTTLStore[K, V]) lockTenantStore(tenantId string) *orderedStore[K, V] {
 	for {
-		tenantStore.mu.Lock()
-
-		if tenantStore.expiryListHeap.Len() == 0 {
-			tenantStore.mu.Unlock()
-			select {
-			case <-t.stopCh:
-				return
-			case <-time.After(500 * time.Millisecond):
-				continue
-			}
+		tenantSpecificOrderedStore := t.tenantStore(tenantId)
+		if tenantSpecificOrderedStore == nil {
+			return nil
 		}
-
-		next := tenantStore.expiryListHeap[0]
-		now := time.Now()
-		delay := next.expiration.Sub(now)
-
-		if delay > 0 {
-			tenantStore.mu.Unlock()
-			select {
-			case <-t.stopCh:
-				return
-			case <-time.After(delay):
-				continue
-			}
+		tenantSpecificOrderedStore.mu.Lock()
+		if !tenantSpecificOrderedStore.deleted {
+			return tenantSpecificOrderedStore
 		}
-
-		// Expired now, pop and handle
-		heap.Pop(&tenantStore.expiryListHeap)
-		if e, ok := tenantStore.entryMap[next.key]; ok {
-			e.expiryFunc(tenantID, next.key)
-			tenantStore.order.Remove(e.element)
-			delete(tenantStore.entryMap, next.key)
-			tenantStore.size.Add(-1)
-		}
-
-		tenantStore.mu.Unlock()
+		tenantSpecificOrderedStore.mu.Unlock()
 	}
 }
 
-func (t *tenantTTLStore) removeOldestForCapacity(tenantId string,
-	tenantSpecificOrderedStore *orderedStore) {
+func (t *tenantTTLStore[K, V]) removeInternal(tenantSpecificOrderedStore *orderedStore[K, V], key K, reason ...Reason) {
+	// Caller must hold tenantSpecificOrderedStore.mu
+	e, ok := tenantSpecificOrderedStore.entryMap[key]
+	if ok {
+		if len(reason) != 0 {
+			t.fire(tenantSpecificOrderedStore, e, reason[0])
+			if reason[0] == Expired {
+				t.metrics.expired.Add(1)
+			}
+		}
+		if e.leased() {
+			delete(tenantSpecificOrderedStore.leases, e.leaseToken)
+		}
+		if e.element != nil {
+			tenantSpecificOrderedStore.order.Remove(e.element, e.priority)
+		}
+		t.stopTimers(tenantSpecificOrderedStore, e)
+		tenantSpecificOrderedStore.access.remove(e)
+		delete(tenantSpecificOrderedStore.entryMap, key)
+		tenantSpecificOrderedStore.size.Add(-1)
+		tenantSpecificOrderedStore.spaceFreed.notify()
+	} else {
+		//fmt.Println("already delete: ", key)
 
-	front := tenantSpecificOrderedStore.order.Front()
-	if front == nil {
-		return
 	}
 
-	key := front.Value.(int64)
-
-	t.removeInternal(tenantId, key, true)
-	return
-
 }
 
-func (t *tenantTTLStore) Stop() {
-	close(t.stopCh)
-	t.wg.Wait()
+// stopTimers takes the heap items of e out of the tenant heaps.
+// Caller must hold tenantSpecificOrderedStore.mu
+func (t *tenantTTLStore[K, V]) stopTimers(tenantSpecificOrderedStore *orderedStore[K, V], e *entry[K, V]) {
+	tenantSpecificOrderedStore.expiryListHeap.remove(e.ttlItem)
+	tenantSpecificOrderedStore.expiryListHeap.remove(e.leaseItem)
+	tenantSpecificOrderedStore.readyListHeap.remove(e.readyItem)
+	e.ttlItem, e.leaseItem, e.readyItem = nil, nil, nil
 }
 
-func (t *tenantTTLStore) RegisterHTTPHandlers(port ...int64) (err error) {
+func (t *tenantTTLStore[K, V]) cleanupTenantLoop(tenantID string, tenantStore *orderedStore[K, V]) {
+	defer t.wg.Done()
 
-	mux := http.NewServeMux()
-	httpPort := int64(defaultPort)
-	if len(port) != 0 {
-		httpPort = port[0]
-	}
-	err = http.ListenAndServe(fmt.Sprintf(":%d", httpPort), mux)
-	if err != nil {
-		return err
-	}
-	// Tenant or Entry details
-	mux.HandleFunc(tenantSpecificUrl, func(w http.ResponseWriter, r *http.Request) {
-		path := strings.TrimPrefix(r.URL.Path, tenantSpecificUrl)
-		parts := strings.Split(path, "/")
+	for {
+		tenantStore.mu.Lock()
+		now := t.cfg.clock.Now()
+		next, ok := t.expireDue(tenantStore, now)
+		t.unlock(tenantStore)
 
-		if len(parts) == 0 || parts[0] == "" {
-			http.Error(w, "tenant ID required", http.StatusBadRequest)
-			return
+		delay := t.cfg.idlePollInterval
+		if ok {
+			delay = next.Sub(now)
 		}
 
-		tenantID := parts[0]
-
-		if len(parts) == 1 {
-			t.handleTenantView(w, tenantID)
+		timer, stop := t.cfg.clock.NewTimer(delay)
+		select {
+		case <-t.stopCh:
+			stop()
 			return
-		}
-
-		if len(parts) == 3 && parts[1] == entryParam {
-			entryIDStr := parts[2]
-			entryID, err := strconv.ParseInt(entryIDStr, 10, 64)
-			if err != nil {
-				http.Error(w, "invalid entry ID", http.StatusBadRequest)
-				return
-			}
-
-			t.handleTenantEntryView(w, tenantID, entryID)
+		case <-tenantStore.stopCh:
+			stop()
 			return
+		case <-tenantStore.wakeCh:
+			stop()
+		case <-timer:
 		}
-
-		http.NotFound(w, r)
-	})
-
-	return nil
+	}
 }
 
-func (t *tenantTTLStore) handleTenantView(w http.ResponseWriter, tenantID string) {
-	t.tenantsMu.RLock()
-	tenantStore, ok := t.tenantOrderedStore[tenantID]
-	t.tenantsMu.RUnlock()
+// expireDue releases delayed items that became ready, removes every item whose TTL has passed
+// and reports the next time either happens, if any.
+// Caller must hold tenantStore.mu
+func (t *tenantTTLStore[K, V]) expireDue(tenantStore *orderedStore[K, V], now time.Time) (next time.Time, ok bool) {
+	nextReady, delayed := t.releaseDue(tenantStore, now)
+	for tenantStore.expiryListHeap.Len() > 0 {
+		top := tenantStore.expiryListHeap[0]
+		if top.expiration.After(now) {
+			next, ok = top.expiration, true
+			break
+		}
 
-	if !ok {
-		http.Error(w, "tenant not found", http.StatusNotFound)
-		return
+		// Expired now, pop and handle; heap items always belong to a stored entry
+		heap.Pop(&tenantStore.expiryListHeap)
+		t.metrics.expiryLag.observe(now.Sub(top.expiration))
+		e := tenantStore.entryMap[top.key]
+		if top == e.leaseItem {
+			t.traceExpiry(tenantStore, top, "lease_expired", now)
+			e.leaseItem = nil
+			t.requeueLeased(tenantStore, e)
+			continue
+		}
+		t.traceExpiry(tenantStore, top, "expired", now)
+		e.ttlItem = nil
+		t.removeInternal(tenantStore, top.key, Expired)
 	}
-
-	tenantStore.mu.RLock()
-	defer tenantStore.mu.RUnlock()
-
-	//now := time.Now()
-	var items []tenantView
-	for k, e := range tenantStore.entryMap {
-		items = append(items, tenantView{
-			Key:        k,
-			Value:      e.value,
-			ExpiryTime: e.expiryTime.Unix(),
-			TTL:        time.Until(e.expiryTime),
-		})
+	if delayed && (!ok || nextReady.Before(next)) {
+		return nextReady, true
 	}
-
-	writeJSON(w, items)
+	return next, ok
 }
 
-func (t *tenantTTLStore) handleTenantEntryView(w http.ResponseWriter, tenantId string, entryID int64) {
+// traceExpiry records an expiry span for a popped heap item.
+// Caller must hold tenantStore.mu
+func (t *tenantTTLStore[K, V]) traceExpiry(tenantStore *orderedStore[K, V], item *expiry[K], outcome string, now time.Time) {
+	if tracer := t.cfg.tracer; tracer != nil {
+		_, end := tracer.Start(context.Background(), SpanExpire, slog.String(AttrTenant, tenantStore.tenantId),
+			slog.Any(AttrKey, item.key), slog.Duration(AttrLag, now.Sub(item.expiration)))
+		end(slog.String(AttrOutcome, outcome))
+	}
+}
 
-	t.tenantsMu.RLock()
-	tenantSpecificOrderedStore, ok := t.tenantOrderedStore[tenantId]
-	t.tenantsMu.RUnlock()
+// releaseDue moves delayed items whose ready time has passed into the tenant queue
+// and reports the next ready time, if any.
+// Caller must hold tenantStore.mu
+func (t *tenantTTLStore[K, V]) releaseDue(tenantStore *orderedStore[K, V], now time.Time) (next time.Time, ok bool) {
+	for tenantStore.readyListHeap.Len() > 0 {
+		top := tenantStore.readyListHeap[0]
+		if top.expiration.After(now) {
+			return top.expiration, true
+		}
 
-	if !ok {
-		http.Error(w, "tenant not found", http.StatusNotFound)
-		return
+		heap.Pop(&tenantStore.readyListHeap)
+		e := tenantStore.entryMap[top.key]
+		e.readyItem = nil
+		t.place(tenantStore, e, e.priority, time.Time{}, now)
 	}
+	return next, false
+}
 
-	tenantSpecificOrderedStore.mu.RLock()
-	defer tenantSpecificOrderedStore.mu.RUnlock()
-
-	e, ok := tenantSpecificOrderedStore.entryMap[entryID]
-	if !ok {
-		http.Error(w, "entry not found", http.StatusNotFound)
+// expiryScheduled wakes whichever goroutine expires tenantStore after exp became its earliest expiration.
+// Caller must hold tenantStore.mu
+func (t *tenantTTLStore[K, V]) expiryScheduled(tenantStore *orderedStore[K, V], exp time.Time) {
+	if t.scheduler != nil {
+		t.scheduler.shardFor(tenantStore.tenantId).schedule(tenantStore, exp)
 		return
 	}
-
-	writeJSON(w, tenantView{
-		Key:        e.id,
-		Value:      e.value,
-		ExpiryTime: e.expiryTime.Unix(),
-		TTL:        time.Until(e.expiryTime),
-	})
+	select {
+	case tenantStore.wakeCh <- struct{}{}:
+	default:
+	}
 }
 
-func writeJSON(w http.ResponseWriter, v any) {
-	w.Header().Set("Content-Type", "application/json")
-	enc := json.NewEncoder(w)
-	enc.SetIndent("", "  ")
-	_ = enc.Encode(v)
+// Stop shuts the store down with ShutdownDrop and waits until it has stopped.
+func (t *tenantTTLStore[K, V]) Stop() {
+	_ = t.Shutdown(context.Background(), ShutdownDrop)
 }
@@ -15,7 +15,7 @@ func init() {
 }
 
 func BenchmarkTenantTTLStoreEnqueue(b *testing.B) {
-	store := NewTenantStore(10000000)
+	store := NewTenantStore[int64, string](WithCapacity(10000000))
 	defer store.Stop()
 
 	f, err := os.Create("cpu.prof")
@@ -25,8 +25,8 @@ func BenchmarkTenantTTLStoreEnqueue(b *testing.B) {
 	pprof.StartCPUProfile(f)
 	defer pprof.StopCPUProfile()
 	tenantID := "t0001"
-	callback := func(tenantId string, key int64) {
-	}
+	callback := ExpiryCallback[int64, string](func(tenantId string, key int64) {
+	})
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		key := int64(i)
@@ -35,11 +35,11 @@ func BenchmarkTenantTTLStoreEnqueue(b *testing.B) {
 }
 
 func BenchmarkTenantTTLStoreConcurrent(b *testing.B) {
-	store := NewTenantStore(10000000)
+	store := NewTenantStore[int64, string](WithCapacity(10000000))
 	defer store.Stop()
 
 	tenantID := "t0001"
-	callback := func(tenantId string, key int64) {}
+	callback := ExpiryCallback[int64, string](func(tenantId string, key int64) {})
 
 	//maxKeys := 100
 
@@ -67,11 +67,11 @@ func BenchmarkTenantTTLStoreConcurrent(b *testing.B) {
 }
 
 func BenchmarkTenantTTLStorePop(b *testing.B) {
-	store := NewTenantStore(10000000)
+	store := NewTenantStore[int64, string](WithCapacity(10000000))
 	defer store.Stop()
-	callback := func(tenantId string, key int64) {
+	callback := ExpiryCallback[int64, string](func(tenantId string, key int64) {
 		fmt.Printf("key: %d, tenantId: %v , fire the init_cancel event", key, tenantId)
-	}
+	})
 	tenantID := "t0001"
 	// pre-fill with items
 	for i := 0; i < 10000; i++ {
@@ -85,11 +85,11 @@ func BenchmarkTenantTTLStorePop(b *testing.B) {
 }
 
 func BenchmarkTenantTTLStoreEnqueueDequeue(b *testing.B) {
-	store := NewTenantStore(10000000)
+	store := NewTenantStore[int64, string](WithCapacity(10000000))
 	defer store.Stop()
-	callback := func(tenantId string, key int64) {
+	callback := ExpiryCallback[int64, string](func(tenantId string, key int64) {
 		fmt.Printf("key: %d, tenantId: %v , fire the init_cancel event", key, tenantId)
-	}
+	})
 	tenantID := "t0001"
 	// pre-fill with items
 	for i := 0; i < 10000; i++ {
@@ -103,11 +103,11 @@ func BenchmarkTenantTTLStoreEnqueueDequeue(b *testing.B) {
 }
 
 func BenchmarkTenantTTLStoreRemove(b *testing.B) {
-	store := NewTenantStore(10000000)
+	store := NewTenantStore[int64, string](WithCapacity(10000000))
 	defer store.Stop()
-	callback := func(tenantId string, key int64) {
+	callback := ExpiryCallback[int64, string](func(tenantId string, key int64) {
 		fmt.Printf("key: %d, tenantId: %v , fire the init_cancel event", key, tenantId)
-	}
+	})
 	tenantID := "t0001"
 	// pre-fill with items
 	for i := 0; i < 10000; i++ {
@@ -119,3 +119,89 @@ func BenchmarkTenantTTLStoreRemove(b *testing.B) {
 		store.Remove(tenantID, int64(i%1000))
 	}
 }
+
+func benchmarkTenantTTLStoreManyTenants(b *testing.B, opts ...Option) {
+	const tenants = 20000
+	tenantIDs := make([]string, tenants)
+	for i := range tenantIDs {
+		tenantIDs[i] = "t" + strconv.Itoa(i)
+	}
+
+	var before runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	store := NewTenantStore[int64, string](append(opts, WithCapacity(1000))...)
+	defer store.Stop()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		store.Enqueue(tenantIDs[i%tenants], int64(i), "value", nil, 5*time.Second)
+	}
+	b.StopTimer()
+
+	var after runtime.MemStats
+	runtime.ReadMemStats(&after)
+	b.ReportMetric(float64(runtime.NumGoroutine()), "goroutines")
+	b.ReportMetric(float64(after.HeapInuse-min(before.HeapInuse, after.HeapInuse))/(1<<20), "heap-MB")
+}
+
+func BenchmarkTenantTTLStoreManyTenantsPerTenantLoop(b *testing.B) {
+	benchmarkTenantTTLStoreManyTenants(b)
+}
+
+func BenchmarkTenantTTLStoreManyTenantsSharedScheduler(b *testing.B) {
+	benchmarkTenantTTLStoreManyTenants(b, WithSharedScheduler(0))
+}
+
+// BenchmarkTenantTTLStoreEnqueueBatch enqueues one batch of new keys per op, and reports the cost per item as well.
+func BenchmarkTenantTTLStoreEnqueueBatch(b *testing.B) {
+	const batchSize = 1000
+	store := NewTenantStore[int64, string](WithCapacity(10000000))
+	defer store.Stop()
+
+	tenantID := "t0001"
+	items := make([]BatchItem[int64, string], batchSize)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := range items {
+			items[j] = BatchItem[int64, string]{Key: int64(i*batchSize + j), Value: "value", TTL: 5 * time.Second}
+		}
+		store.EnqueueBatch(tenantID, items)
+	}
+	b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*batchSize), "ns/item")
+}
+
+// BenchmarkTenantTTLStoreUpdateSameKeys re-enqueues and removes a fixed set of keys.
+// The expiry heap must stay at one item per stored key however many updates run.
+func BenchmarkTenantTTLStoreUpdateSameKeys(b *testing.B) {
+	const keys = 1000
+	store := NewTenantStore[int64, string](WithCapacity(10000000))
+	defer store.Stop()
+
+	tenantID := "t0001"
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		key := int64(i % keys)
+		store.Enqueue(tenantID, key, "value", nil, time.Duration(1+i%10)*time.Second)
+		if i%7 == 0 {
+			store.Remove(tenantID, int64((i/7)%keys))
+		}
+	}
+	b.StopTimer()
+
+	tenantStore, _ := store.GetTenantOrderedMap(tenantID)
+	tenantStore.mu.RLock()
+	b.ReportMetric(float64(tenantStore.expiryListHeap.Len()), "heap-items")
+	tenantStore.mu.RUnlock()
+
+	var stats runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&stats)
+	b.ReportMetric(float64(stats.HeapInuse)/(1<<20), "heap-MB")
+}
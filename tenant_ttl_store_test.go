@@ -1,9 +1,10 @@
 package smartqueue
 
 import (
-	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/smartqueue/smartqueuetest"
 )
 
 type mockEntry struct {
@@ -12,11 +13,11 @@ type mockEntry struct {
 }
 
 func TestTenantTTLStoresEnqueue(t *testing.T) {
-	var callbackTriggered int32
+	callbackTriggered := make(chan int64, 10)
 
-	mockCallback := func(tenantId string, key int64) {
-		atomic.AddInt32(&callbackTriggered, 1)
-	}
+	mockCallback := ExpiryCallback[int64, any](func(tenantId string, key int64) {
+		callbackTriggered <- key
+	})
 
 	type fields struct {
 		capacity int64
@@ -25,7 +26,7 @@ func TestTenantTTLStoresEnqueue(t *testing.T) {
 		tenantId string
 		key      int64
 		value    interface{}
-		callback func(tenantId string, key int64)
+		callback Callback[int64, any]
 		ttl      time.Duration
 	}
 
@@ -119,7 +120,11 @@ func TestTenantTTLStoresEnqueue(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			store := NewTenantStore(tt.fields.capacity).(*tenantTTLStore)
+			clock := smartqueuetest.NewFakeClock(time.Now())
+			store := NewTenantStore[int64, any](
+				WithCapacity(tt.fields.capacity),
+				WithClock(clock),
+			).(*tenantTTLStore[int64, any])
 			defer store.Stop()
 
 			// enqueue first
@@ -166,18 +171,25 @@ func TestTenantTTLStoresEnqueue(t *testing.T) {
 				}
 			}
 
-			// Wait for possible expiry
-			time.Sleep(tt.args.ttl + 200*time.Millisecond)
+			// Move past the expiry and the idle poll of the cleanup loop
+			clock.Advance(tt.args.ttl + time.Second)
 
-			if tt.expectCallback && atomic.LoadInt32(&callbackTriggered) == 0 {
-				t.Errorf("%s: expected callback to trigger on expiry", tt.name)
+			if tt.expectCallback {
+				select {
+				case <-callbackTriggered:
+				case <-time.After(time.Second):
+					t.Errorf("%s: expected callback to trigger on expiry", tt.name)
+				}
+			}
+			for len(callbackTriggered) > 0 {
+				<-callbackTriggered
 			}
 		})
 	}
 }
 
 func TestTenantTTLStorePop(t *testing.T) {
-	mockCallback := func(tenantId string, key int64) {}
+	mockCallback := ExpiryCallback[int64, any](func(tenantId string, key int64) {})
 
 	type fields struct {
 		capacity int64
@@ -191,7 +203,7 @@ func TestTenantTTLStorePop(t *testing.T) {
 		name        string
 		fields      fields
 		args        args
-		setup       func(store *tenantTTLStore)
+		setup       func(store *tenantTTLStore[int64, any], clock *smartqueuetest.FakeClock)
 		wantExist   bool
 		wantNil     bool
 		description string
@@ -205,7 +217,7 @@ func TestTenantTTLStorePop(t *testing.T) {
 				tenantID: "unknownTenant",
 				key:      1,
 			},
-			setup:       func(store *tenantTTLStore) {},
+			setup:       func(store *tenantTTLStore[int64, any], clock *smartqueuetest.FakeClock) {},
 			wantExist:   false,
 			wantNil:     true,
 			description: "Should return false when tenant not found",
@@ -219,7 +231,7 @@ func TestTenantTTLStorePop(t *testing.T) {
 				tenantID: "t0001",
 				key:      999,
 			},
-			setup: func(store *tenantTTLStore) {
+			setup: func(store *tenantTTLStore[int64, any], clock *smartqueuetest.FakeClock) {
 				store.Enqueue("t0001", 1, mockEntry{Id: 1, Name: "A"}, mockCallback, 1*time.Second)
 			},
 			wantExist:   false,
@@ -235,9 +247,9 @@ func TestTenantTTLStorePop(t *testing.T) {
 				tenantID: "t0002",
 				key:      2,
 			},
-			setup: func(store *tenantTTLStore) {
+			setup: func(store *tenantTTLStore[int64, any], clock *smartqueuetest.FakeClock) {
 				store.Enqueue("t0002", 2, mockEntry{Id: 2, Name: "Expired"}, mockCallback, 100*time.Millisecond)
-				time.Sleep(150 * time.Millisecond) // let it expire
+				clock.Advance(150 * time.Millisecond) // let it expire
 			},
 			wantExist:   false,
 			wantNil:     true,
@@ -252,7 +264,7 @@ func TestTenantTTLStorePop(t *testing.T) {
 				tenantID: "t0003",
 				key:      3,
 			},
-			setup: func(store *tenantTTLStore) {
+			setup: func(store *tenantTTLStore[int64, any], clock *smartqueuetest.FakeClock) {
 				store.Enqueue("t0003", 3, mockEntry{Id: 3, Name: "Valid"}, mockCallback, 1*time.Second)
 			},
 			wantExist:   true,
@@ -263,11 +275,15 @@ func TestTenantTTLStorePop(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			store := NewTenantStore(tt.fields.capacity).(*tenantTTLStore)
+			clock := smartqueuetest.NewFakeClock(time.Now())
+			store := NewTenantStore[int64, any](
+				WithCapacity(tt.fields.capacity),
+				WithClock(clock),
+			).(*tenantTTLStore[int64, any])
 			defer store.Stop()
 
 			// setup before test
-			tt.setup(store)
+			tt.setup(store, clock)
 
 			val, exist := store.Pop(tt.args.tenantID, tt.args.key)
 
@@ -296,7 +312,7 @@ func TestTenantTTLStorePop(t *testing.T) {
 }
 
 func TestTenantTTLStoreDequeue(t *testing.T) {
-	mockCallback := func(tenantId string, key int64) {}
+	mockCallback := ExpiryCallback[int64, any](func(tenantId string, key int64) {})
 
 	type fields struct {
 		capacity int64
@@ -309,7 +325,7 @@ func TestTenantTTLStoreDequeue(t *testing.T) {
 		name        string
 		fields      fields
 		args        args
-		setup       func(store *tenantTTLStore)
+		setup       func(store *tenantTTLStore[int64, any], clock *smartqueuetest.FakeClock)
 		wantExist   bool
 		wantNil     bool
 		wantKey     int64
@@ -323,7 +339,7 @@ func TestTenantTTLStoreDequeue(t *testing.T) {
 			args: args{
 				tenantId: "unknownTenant",
 			},
-			setup:       func(store *tenantTTLStore) {},
+			setup:       func(store *tenantTTLStore[int64, any], clock *smartqueuetest.FakeClock) {},
 			wantExist:   false,
 			wantNil:     true,
 			wantKey:     0,
@@ -337,7 +353,7 @@ func TestTenantTTLStoreDequeue(t *testing.T) {
 			args: args{
 				tenantId: "t0001",
 			},
-			setup: func(store *tenantTTLStore) {
+			setup: func(store *tenantTTLStore[int64, any], clock *smartqueuetest.FakeClock) {
 				store.tenantStore("t0001") // initialize empty tenant
 			},
 			wantExist:   false,
@@ -353,9 +369,9 @@ func TestTenantTTLStoreDequeue(t *testing.T) {
 			args: args{
 				tenantId: "t0002",
 			},
-			setup: func(store *tenantTTLStore) {
+			setup: func(store *tenantTTLStore[int64, any], clock *smartqueuetest.FakeClock) {
 				store.Enqueue("t0002", 10, mockEntry{Id: 10, Name: "Expired"}, mockCallback, 50*time.Millisecond)
-				time.Sleep(100 * time.Millisecond) // let TTL expire
+				clock.Advance(100 * time.Millisecond) // let TTL expire
 			},
 			wantExist:   false,
 			wantNil:     true,
@@ -370,7 +386,7 @@ func TestTenantTTLStoreDequeue(t *testing.T) {
 			args: args{
 				tenantId: "t0003",
 			},
-			setup: func(store *tenantTTLStore) {
+			setup: func(store *tenantTTLStore[int64, any], clock *smartqueuetest.FakeClock) {
 				store.Enqueue("t0003", 101, mockEntry{Id: 101, Name: "Item101"}, mockCallback, 1*time.Second)
 				store.Enqueue("t0003", 102, mockEntry{Id: 102, Name: "Item102"}, mockCallback, 1*time.Second)
 			},
@@ -383,10 +399,14 @@ func TestTenantTTLStoreDequeue(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			store := NewTenantStore(tt.fields.capacity).(*tenantTTLStore)
+			clock := smartqueuetest.NewFakeClock(time.Now())
+			store := NewTenantStore[int64, any](
+				WithCapacity(tt.fields.capacity),
+				WithClock(clock),
+			).(*tenantTTLStore[int64, any])
 			defer store.Stop()
 
-			tt.setup(store)
+			tt.setup(store, clock)
 
 			key, val, exist := store.Dequeue(tt.args.tenantId)
 
@@ -419,7 +439,7 @@ func TestTenantTTLStoreDequeue(t *testing.T) {
 }
 
 func TestTenantTTLStoreRemove(t *testing.T) {
-	mockCallback := func(tenantId string, key int64) {}
+	mockCallback := ExpiryCallback[int64, any](func(tenantId string, key int64) {})
 
 	type fields struct {
 		capacity int64
@@ -433,7 +453,7 @@ func TestTenantTTLStoreRemove(t *testing.T) {
 		name        string
 		fields      fields
 		args        args
-		setup       func(store *tenantTTLStore)
+		setup       func(store *tenantTTLStore[int64, any], clock *smartqueuetest.FakeClock)
 		expectExist bool
 		description string
 	}{
@@ -446,7 +466,7 @@ func TestTenantTTLStoreRemove(t *testing.T) {
 				tenantId: "unknownTenant",
 				key:      1,
 			},
-			setup:       func(store *tenantTTLStore) {},
+			setup:       func(store *tenantTTLStore[int64, any], clock *smartqueuetest.FakeClock) {},
 			expectExist: false,
 			description: "Remove on unknown tenant should do nothing",
 		},
@@ -459,7 +479,7 @@ func TestTenantTTLStoreRemove(t *testing.T) {
 				tenantId: "t0001",
 				key:      999,
 			},
-			setup: func(store *tenantTTLStore) {
+			setup: func(store *tenantTTLStore[int64, any], clock *smartqueuetest.FakeClock) {
 				store.tenantStore("t0001") // initialize tenant store without inserting key
 			},
 			expectExist: false,
@@ -474,7 +494,7 @@ func TestTenantTTLStoreRemove(t *testing.T) {
 				tenantId: "t0002",
 				key:      123,
 			},
-			setup: func(store *tenantTTLStore) {
+			setup: func(store *tenantTTLStore[int64, any], clock *smartqueuetest.FakeClock) {
 				store.Enqueue("t0002", 123, mockEntry{Id: 123, Name: "to_remove"}, mockCallback, 5*time.Second)
 			},
 			expectExist: false,
@@ -484,10 +504,14 @@ func TestTenantTTLStoreRemove(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			store := NewTenantStore(tt.fields.capacity).(*tenantTTLStore)
+			clock := smartqueuetest.NewFakeClock(time.Now())
+			store := NewTenantStore[int64, any](
+				WithCapacity(tt.fields.capacity),
+				WithClock(clock),
+			).(*tenantTTLStore[int64, any])
 			defer store.Stop()
 
-			tt.setup(store)
+			tt.setup(store, clock)
 
 			// Act
 			store.Remove(tt.args.tenantId, tt.args.key)
@@ -503,3 +527,53 @@ func TestTenantTTLStoreRemove(t *testing.T) {
 		})
 	}
 }
+
+func TestParseKey(t *testing.T) {
+	t.Run("int64 key", func(t *testing.T) {
+		key, err := parseKey[int64]("121")
+		if err != nil || key != 121 {
+			t.Errorf("expected key=121, got %v (err=%v)", key, err)
+		}
+	})
+	t.Run("invalid int64 key", func(t *testing.T) {
+		if _, err := parseKey[int64]("abc"); err == nil {
+			t.Errorf("expected error for invalid int64 key")
+		}
+	})
+	t.Run("int64 key with trailing input", func(t *testing.T) {
+		for _, s := range []string{"12abc", "12 13", "12\n"} {
+			if key, err := parseKey[int64](s); err == nil {
+				t.Errorf("expected error for %q, got key=%v", s, key)
+			}
+		}
+	})
+	t.Run("uint8 key out of range", func(t *testing.T) {
+		if _, err := parseKey[uint8]("256"); err == nil {
+			t.Errorf("expected error for out of range uint8 key")
+		}
+	})
+	t.Run("string key", func(t *testing.T) {
+		key, err := parseKey[string]("order 42")
+		if err != nil || key != "order 42" {
+			t.Errorf("expected key=%q, got %q (err=%v)", "order 42", key, err)
+		}
+	})
+}
+
+func TestTenantTTLStoreTypedValues(t *testing.T) {
+	store := NewTenantStore[string, mockEntry](WithCapacity(10))
+	defer store.Stop()
+
+	store.Enqueue("t0001", "order-1", mockEntry{Id: 1, Name: "first"}, nil, time.Second)
+	store.Enqueue("t0001", "order-2", mockEntry{Id: 2, Name: "second"}, nil, time.Second)
+
+	val, ok := store.Pop("t0001", "order-2")
+	if !ok || val.Name != "second" {
+		t.Errorf("expected Pop to return second, got %+v (ok=%v)", val, ok)
+	}
+
+	key, val, ok := store.Dequeue("t0001")
+	if !ok || key != "order-1" || val.Id != 1 {
+		t.Errorf("expected Dequeue to return order-1, got %v %+v (ok=%v)", key, val, ok)
+	}
+}
@@ -0,0 +1,94 @@
+package smartqueue
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Span names passed to Tracer.Start.
+const (
+	SpanEnqueue      = "smartqueue.enqueue"
+	SpanDequeue      = "smartqueue.dequeue"
+	SpanPop          = "smartqueue.pop"
+	SpanRemove       = "smartqueue.remove"
+	SpanExpire       = "smartqueue.expire"
+	SpanCallback     = "smartqueue.callback"
+	SpanEnqueueBatch = "smartqueue.enqueue_batch"
+	SpanDequeueBatch = "smartqueue.dequeue_batch"
+	SpanRemoveBatch  = "smartqueue.remove_batch"
+	SpanLease        = "smartqueue.lease"
+	SpanAck          = "smartqueue.ack"
+	SpanNack         = "smartqueue.nack"
+	SpanExtendLease  = "smartqueue.extend_lease"
+)
+
+// Span attribute keys.
+const (
+	AttrTenant  = "smartqueue.tenant"
+	AttrKey     = "smartqueue.key"
+	AttrOutcome = "smartqueue.outcome"
+	AttrReason  = "smartqueue.reason"
+	// AttrLag is how late an expiry fired after the expiry time.
+	AttrLag = "smartqueue.lag"
+	// AttrCount is the number of items a batch call was given or asked for.
+	AttrCount = "smartqueue.count"
+	// AttrProcessed is the number of items a batch call enqueued, dequeued or removed.
+	AttrProcessed = "smartqueue.processed"
+)
+
+// Tracer is called around Enqueue, Dequeue, Pop, Remove, their batch forms, leases, expiries and callbacks,
+// so the lifecycle of an item can be followed next to request traces. Spans carry the tenant and key as
+// attributes, and the end function receives the outcome: "ok" or the error text for calls that can fail,
+// "hit" or "miss" for lookups, dequeues and leases, with the error text when a waiting dequeue fails,
+// "expired" or "lease_expired" for expiries, and "ok" or "panic" for callbacks. A batch call that found
+// nothing is a "miss", and EnqueueBatch ends with the error of its first rejected item.
+// Expiry spans are started and ended while the tenant lock is held, so a Tracer must not block or call
+// back into the store. It only uses standard library types, so it can be adapted to OpenTelemetry, and
+// smartqueuetest.TraceRecorder need not import this package.
+type Tracer interface {
+	// Start begins a span and returns the context that carries it and the function that ends it.
+	Start(ctx context.Context, name string, attrs ...slog.Attr) (context.Context, func(attrs ...slog.Attr))
+}
+
+// hitOutcome is the outcome attribute of a lookup.
+func hitOutcome(found bool) slog.Attr {
+	if found {
+		return slog.String(AttrOutcome, "hit")
+	}
+	return slog.String(AttrOutcome, "miss")
+}
+
+// dequeuedAttrs ends the span of a dequeue that waits on several tenants.
+func dequeuedAttrs[K comparable](tenantId string, key K, err error) []slog.Attr {
+	if err != nil {
+		return []slog.Attr{errorOutcome(err)}
+	}
+	return []slog.Attr{hitOutcome(true), slog.String(AttrTenant, tenantId), slog.Any(AttrKey, key)}
+}
+
+// enqueuedAttrs ends the span of EnqueueBatch.
+func enqueuedAttrs(results []EnqueueResult) []slog.Attr {
+	var err error
+	stored := 0
+	for _, result := range results {
+		if result.Err == nil {
+			stored++
+		} else if err == nil {
+			err = result.Err
+		}
+	}
+	return []slog.Attr{errorOutcome(err), slog.Int(AttrProcessed, stored)}
+}
+
+// processedAttrs ends the span of a batch call that dequeued or removed n items.
+func processedAttrs(n int) []slog.Attr {
+	return []slog.Attr{hitOutcome(n > 0), slog.Int(AttrProcessed, n)}
+}
+
+// errorOutcome is the outcome attribute of an operation that returned err.
+func errorOutcome(err error) slog.Attr {
+	if err != nil {
+		return slog.String(AttrOutcome, err.Error())
+	}
+	return slog.String(AttrOutcome, "ok")
+}
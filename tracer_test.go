@@ -0,0 +1,167 @@
+package smartqueue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/smartqueue/smartqueuetest"
+)
+
+var _ Tracer = (*smartqueuetest.TraceRecorder)(nil)
+
+func TestTenantTTLStoreTracer(t *testing.T) {
+	clock := smartqueuetest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	recorder := smartqueuetest.NewTraceRecorder()
+	store := NewTenantStore[int64, string](WithClock(clock), WithTracer(recorder),
+		WithTenantConfig("t0002", TenantConfig{Capacity: 1, EvictionPolicy: RejectNew}))
+	defer store.Stop()
+
+	expired := make(chan struct{})
+	store.Enqueue("t0001", 1, "one", func(event Event[int64, string]) {
+		if event.Reason == Expired {
+			close(expired)
+		}
+	}, time.Second)
+	store.Enqueue("t0001", 2, "two", func(Event[int64, string]) { panic("boom") }, time.Hour)
+	store.Enqueue("t0001", 3, "three", nil, time.Hour)
+	store.Enqueue("t0002", 1, "one", nil, time.Hour)
+	store.Enqueue("t0002", 2, "two", nil, time.Hour)
+	store.Pop("t0001", 3)
+	store.Pop("t0001", 9)
+	store.Remove("t0001", 2)
+	store.Remove("t0001", 9)
+
+	clock.Advance(3 * time.Second)
+	select {
+	case <-expired:
+	case <-time.After(time.Second):
+		t.Fatal("expected key 1 to expire")
+	}
+	// the callback span ends only once the callback has returned
+	callbackEnded := func() bool {
+		for _, s := range recorder.Spans(SpanCallback) {
+			if key, _ := s.Attr(AttrKey); s.Ended && key.Any() == int64(1) {
+				return true
+			}
+		}
+		return false
+	}
+	for deadline := time.Now().Add(time.Second); !callbackEnded() && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	store.Dequeue("t0001")
+	store.Dequeue("t0001")
+	if _, _, err := store.DequeueWait(context.Background(), "t0002"); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	store.EnqueueBatch("t0003", []BatchItem[int64, string]{
+		{Key: 1, Value: "one", TTL: time.Hour},
+		{Key: 2, Value: "two", TTL: time.Hour},
+		{Key: 3, Value: "three", TTL: time.Hour},
+	})
+	store.DequeueN("t0003", 1)
+	store.RemoveBatch("t0003", []int64{2, 9})
+	store.RemoveBatch("t0003", []int64{9})
+	lease, _ := store.Lease("t0003", time.Minute)
+	store.Lease("t0003", time.Minute)
+	store.ExtendLease("t0003", lease.Token, time.Minute)
+	store.Nack("t0003", lease.Token)
+	lease, _ = store.Lease("t0003", time.Minute)
+	store.Ack("t0003", lease.Token)
+	store.Ack("t0003", lease.Token)
+
+	type span struct {
+		name    string
+		tenant  string
+		key     any
+		outcome string
+	}
+	tests := []struct {
+		name  string
+		names []string
+		want  []span
+	}{
+		{name: "Enqueue", names: []string{SpanEnqueue}, want: []span{
+			{SpanEnqueue, "t0001", int64(1), "ok"},
+			{SpanEnqueue, "t0001", int64(2), "ok"},
+			{SpanEnqueue, "t0001", int64(3), "ok"},
+			{SpanEnqueue, "t0002", int64(1), "ok"},
+			{SpanEnqueue, "t0002", int64(2), ErrCapacityReached.Error()},
+		}},
+		{name: "Pop and Remove", names: []string{SpanPop, SpanRemove}, want: []span{
+			{SpanPop, "t0001", int64(3), "hit"},
+			{SpanPop, "t0001", int64(9), "miss"},
+			{SpanRemove, "t0001", int64(2), "hit"},
+			{SpanRemove, "t0001", int64(9), "miss"},
+		}},
+		{name: "Expiry and callbacks", names: []string{SpanExpire, SpanCallback}, want: []span{
+			{SpanCallback, "t0001", int64(2), "panic"},
+			{SpanExpire, "t0001", int64(1), "expired"},
+			{SpanCallback, "t0001", int64(1), "ok"},
+		}},
+		{name: "Dequeue", names: []string{SpanDequeue}, want: []span{
+			{SpanDequeue, "t0001", int64(3), "hit"},
+			{SpanDequeue, "t0001", nil, "miss"},
+			{SpanDequeue, "t0002", int64(1), "hit"},
+		}},
+		{name: "Batches", names: []string{SpanEnqueueBatch, SpanDequeueBatch, SpanRemoveBatch}, want: []span{
+			{SpanEnqueueBatch, "t0003", nil, "ok"},
+			{SpanDequeueBatch, "t0003", nil, "hit"},
+			{SpanRemoveBatch, "t0003", nil, "hit"},
+			{SpanRemoveBatch, "t0003", nil, "miss"},
+		}},
+		{name: "Leases", names: []string{SpanLease, SpanAck, SpanNack, SpanExtendLease}, want: []span{
+			{SpanLease, "t0003", int64(3), "hit"},
+			{SpanLease, "t0003", nil, "miss"},
+			{SpanExtendLease, "t0003", int64(3), "ok"},
+			{SpanNack, "t0003", int64(3), "ok"},
+			{SpanLease, "t0003", int64(3), "hit"},
+			{SpanAck, "t0003", int64(3), "ok"},
+			{SpanAck, "t0003", nil, ErrLeaseNotFound.Error()},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spans := recorder.Spans(tt.names...)
+			if len(spans) != len(tt.want) {
+				t.Fatalf("%s: expected %d spans, got %+v", tt.name, len(tt.want), spans)
+			}
+			for i, want := range tt.want {
+				got := spans[i]
+				tenant, _ := got.Attr(AttrTenant)
+				outcome, _ := got.Attr(AttrOutcome)
+				var key any
+				if v, ok := got.Attr(AttrKey); ok {
+					key = v.Any()
+				}
+				if !got.Ended || got.Name != want.name || tenant.String() != want.tenant || key != want.key || outcome.String() != want.outcome {
+					t.Errorf("%s: expected span %d to be %+v, got %+v", tt.name, i, want, got)
+				}
+			}
+		})
+	}
+
+	if processed, ok := recorder.Spans(SpanRemoveBatch)[0].Attr(AttrProcessed); !ok || processed.Int64() != 1 {
+		t.Errorf("expected 1 item processed by the batch remove, got %v", processed)
+	}
+	if lag, ok := recorder.Spans(SpanExpire)[0].Attr(AttrLag); !ok || lag.Duration() != 2*time.Second {
+		t.Errorf("expected an expiry lag of 2s, got %v", lag)
+	}
+}
+
+func TestTenantTTLStoreNoTracerAllocations(t *testing.T) {
+	store := NewTenantStore[int64, string]()
+	defer store.Stop()
+	store.Enqueue("t0001", 1, "one", nil, time.Hour)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		store.Pop("t0001", 1)
+		store.Remove("t0001", 2)
+	})
+	if allocs != 0 {
+		t.Errorf("expected no allocations without a tracer, got %v", allocs)
+	}
+}
@@ -0,0 +1,102 @@
+package smartqueue
+
+import "time"
+
+// NoExpiry is the remaining TTL reported for an item that does not expire.
+const NoExpiry time.Duration = -1
+
+// TTL returns the remaining time to live of key, or NoExpiry for a persisted item.
+func (t *tenantTTLStore[K, V]) TTL(tenantId string, key K) (ttl time.Duration, ok bool) {
+	tenantSpecificOrderedStore, ok := t.GetTenantOrderedMap(tenantId)
+	if !ok {
+		return 0, false
+	}
+
+	tenantSpecificOrderedStore.mu.RLock()
+	defer tenantSpecificOrderedStore.mu.RUnlock()
+
+	e, ok := tenantSpecificOrderedStore.entryMap[key]
+	if !ok {
+		return 0, false
+	}
+	return e.remaining(t.cfg.clock.Now()), true
+}
+
+// Touch restarts the TTL of key from now, for sliding expiration on access. A persisted item stays persisted,
+// and the TTL of a delayed item restarts from its ready time.
+func (t *tenantTTLStore[K, V]) Touch(tenantId string, key K) bool {
+	return t.withExpiry(tenantId, key, true, func(e *entry[K, V], now time.Time) time.Time {
+		if e.expiryTime.IsZero() {
+			return e.expiryTime
+		}
+		return e.ttlStart(now).Add(e.ttl)
+	})
+}
+
+// ExtendTTL moves the expiry of key later by d, up to the tenant MaxTTL. A persisted item stays persisted.
+func (t *tenantTTLStore[K, V]) ExtendTTL(tenantId string, key K, d time.Duration) bool {
+	return t.withExpiry(tenantId, key, false, func(e *entry[K, V], now time.Time) time.Time {
+		if e.expiryTime.IsZero() {
+			return e.expiryTime
+		}
+		return e.expiryTime.Add(d)
+	})
+}
+
+// SetExpiry sets the absolute expiry time of key, up to the tenant MaxTTL.
+func (t *tenantTTLStore[K, V]) SetExpiry(tenantId string, key K, at time.Time) bool {
+	return t.withExpiry(tenantId, key, false, func(e *entry[K, V], now time.Time) time.Time {
+		return at
+	})
+}
+
+// Persist removes the expiry of key, so it stays until it is dequeued, removed or evicted.
+func (t *tenantTTLStore[K, V]) Persist(tenantId string, key K) bool {
+	return t.withExpiry(tenantId, key, false, func(e *entry[K, V], now time.Time) time.Time {
+		return time.Time{}
+	})
+}
+
+// withExpiry sets the expiry of key to the time returned by fn, capped by the tenant MaxTTL,
+// recording an access of key when access is set. An item already expired is dropped instead.
+func (t *tenantTTLStore[K, V]) withExpiry(tenantId string, key K, access bool, fn func(e *entry[K, V], now time.Time) time.Time) bool {
+	tenantSpecificOrderedStore, ok := t.GetTenantOrderedMap(tenantId)
+	if !ok {
+		return false
+	}
+
+	tenantSpecificOrderedStore.mu.Lock()
+	defer t.unlock(tenantSpecificOrderedStore)
+
+	e, ok := tenantSpecificOrderedStore.entryMap[key]
+	if !ok {
+		return false
+	}
+	now := t.cfg.clock.Now()
+	if e.expired(now) {
+		t.removeInternal(tenantSpecificOrderedStore, key, Expired)
+		return false
+	}
+
+	if access {
+		tenantSpecificOrderedStore.touchAccess(e, now)
+	}
+	t.setExpiry(tenantSpecificOrderedStore, e, tenantSpecificOrderedStore.expiryFor(e, fn(e, now), now))
+	tenantSpecificOrderedStore.lastActive = now
+	return true
+}
+
+// setExpiry changes the expiry of e, fixing its heap item in place. A zero exp persists the item.
+// Caller must hold tenantSpecificOrderedStore.mu
+func (t *tenantTTLStore[K, V]) setExpiry(tenantSpecificOrderedStore *orderedStore[K, V], e *entry[K, V], exp time.Time) {
+	e.expiryTime = exp
+	if exp.IsZero() {
+		tenantSpecificOrderedStore.expiryListHeap.remove(e.ttlItem)
+		e.ttlItem = nil
+		return
+	}
+	e.ttlItem = tenantSpecificOrderedStore.expiryListHeap.set(e.ttlItem, tenantSpecificOrderedStore.tenantId, e.id, exp)
+	if tenantSpecificOrderedStore.expiryListHeap.top(e.ttlItem) {
+		t.expiryScheduled(tenantSpecificOrderedStore, exp)
+	}
+}
@@ -0,0 +1,190 @@
+package smartqueue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/smartqueue/smartqueuetest"
+)
+
+func TestTenantTTLStoreTTLOperations(t *testing.T) {
+	tests := []struct {
+		name       string
+		act        func(store Queue[int64, any], now time.Time) bool
+		wantOk     bool
+		wantTTL    time.Duration
+		wantExists bool
+	}{
+		{
+			name:       "TTL of an untouched item",
+			act:        func(store Queue[int64, any], now time.Time) bool { return true },
+			wantOk:     true,
+			wantTTL:    50 * time.Millisecond,
+			wantExists: false,
+		},
+		{
+			name:       "Touch restarts the TTL",
+			act:        func(store Queue[int64, any], now time.Time) bool { return store.Touch("t0001", 1) },
+			wantOk:     true,
+			wantTTL:    50 * time.Millisecond,
+			wantExists: false,
+		},
+		{
+			name:       "ExtendTTL moves the expiry later",
+			act:        func(store Queue[int64, any], now time.Time) bool { return store.ExtendTTL("t0001", 1, time.Hour) },
+			wantOk:     true,
+			wantTTL:    time.Hour + 50*time.Millisecond,
+			wantExists: true,
+		},
+		{
+			name: "SetExpiry sets an absolute time",
+			act: func(store Queue[int64, any], now time.Time) bool {
+				return store.SetExpiry("t0001", 1, now.Add(time.Hour))
+			},
+			wantOk:     true,
+			wantTTL:    time.Hour,
+			wantExists: true,
+		},
+		{
+			name:       "Persist removes the expiry",
+			act:        func(store Queue[int64, any], now time.Time) bool { return store.Persist("t0001", 1) },
+			wantOk:     true,
+			wantTTL:    NoExpiry,
+			wantExists: true,
+		},
+		{
+			name: "Touch keeps a persisted item persisted",
+			act: func(store Queue[int64, any], now time.Time) bool {
+				return store.Persist("t0001", 1) && store.Touch("t0001", 1)
+			},
+			wantOk:     true,
+			wantTTL:    NoExpiry,
+			wantExists: true,
+		},
+		{
+			name:       "Unknown key",
+			act:        func(store Queue[int64, any], now time.Time) bool { return store.Touch("t0001", 2) },
+			wantOk:     false,
+			wantTTL:    50 * time.Millisecond,
+			wantExists: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := smartqueuetest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+			store := NewTenantStore[int64, any](WithClock(clock))
+			defer store.Stop()
+
+			store.Enqueue("t0001", 1, "value", nil, 50*time.Millisecond)
+			if ok := tt.act(store, clock.Now()); ok != tt.wantOk {
+				t.Errorf("%s: expected %v, got %v", tt.name, tt.wantOk, ok)
+			}
+
+			ttl, ok := store.TTL("t0001", 1)
+			if !ok {
+				t.Fatalf("%s: expected the key to exist", tt.name)
+			}
+			if ttl != tt.wantTTL {
+				t.Errorf("%s: expected TTL %v, got %v", tt.name, tt.wantTTL, ttl)
+			}
+
+			clock.Advance(100 * time.Millisecond)
+			if _, ok := store.Pop("t0001", 1); ok != tt.wantExists {
+				t.Errorf("%s: expected exists %v, got %v", tt.name, tt.wantExists, ok)
+			}
+		})
+	}
+}
+
+func TestTenantTTLStoreTouchSlides(t *testing.T) {
+	clock := smartqueuetest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	store := NewTenantStore[int64, any](WithClock(clock))
+	defer store.Stop()
+
+	store.Enqueue("t0001", 1, "value", nil, 60*time.Millisecond)
+	for i := 0; i < 5; i++ {
+		clock.Advance(30 * time.Millisecond)
+		if !store.Touch("t0001", 1) {
+			t.Fatalf("touch %d: expected the key to be alive", i)
+		}
+	}
+	if ttl, ok := store.TTL("t0001", 1); !ok || ttl != 60*time.Millisecond {
+		t.Errorf("expected the last touch to restart the TTL, got %v, %v", ttl, ok)
+	}
+
+	tenantStore, _ := store.(*tenantTTLStore[int64, any]).GetTenantOrderedMap("t0001")
+	tenantStore.mu.RLock()
+	heapLen := tenantStore.expiryListHeap.Len()
+	tenantStore.mu.RUnlock()
+	if heapLen != 1 {
+		t.Errorf("expected touches to update the heap in place, got %d heap items", heapLen)
+	}
+}
+
+func TestTenantTTLStoreExpiryBounds(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     int64
+		act     func(store Queue[int64, any], now time.Time) bool
+		wantTTL time.Duration
+	}{
+		{
+			name: "Touch restarts a delayed item from its ready time",
+			key:  2,
+			act: func(store Queue[int64, any], now time.Time) bool {
+				return store.Touch("t0001", 2)
+			},
+			wantTTL: time.Hour + time.Minute,
+		},
+		{
+			name: "ExtendTTL is capped by MaxTTL",
+			key:  1,
+			act: func(store Queue[int64, any], now time.Time) bool {
+				return store.ExtendTTL("t0001", 1, 2*time.Hour)
+			},
+			wantTTL: time.Hour,
+		},
+		{
+			name: "SetExpiry is capped by MaxTTL",
+			key:  1,
+			act: func(store Queue[int64, any], now time.Time) bool {
+				return store.SetExpiry("t0001", 1, now.Add(3*time.Hour))
+			},
+			wantTTL: time.Hour,
+		},
+		{
+			name: "SetExpiry caps a delayed item from its ready time",
+			key:  2,
+			act: func(store Queue[int64, any], now time.Time) bool {
+				return store.SetExpiry("t0001", 2, now.Add(3*time.Hour))
+			},
+			wantTTL: 2 * time.Hour,
+		},
+		{
+			name: "SetExpiry within MaxTTL is kept",
+			key:  1,
+			act: func(store Queue[int64, any], now time.Time) bool {
+				return store.SetExpiry("t0001", 1, now.Add(30*time.Minute))
+			},
+			wantTTL: 30 * time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := smartqueuetest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+			store := NewTenantStore[int64, any](WithClock(clock), WithTenantConfig("t0001", TenantConfig{MaxTTL: time.Hour}))
+			defer store.Stop()
+
+			store.Enqueue("t0001", 1, "ready", nil, time.Minute)
+			store.EnqueueAfter("t0001", 2, "delayed", time.Hour, nil, time.Minute)
+			if !tt.act(store, clock.Now()) {
+				t.Fatalf("%s: expected the key to exist", tt.name)
+			}
+			if ttl, ok := store.TTL("t0001", tt.key); !ok || ttl != tt.wantTTL {
+				t.Errorf("%s: expected TTL %v, got %v, %v", tt.name, tt.wantTTL, ttl, ok)
+			}
+		})
+	}
+}